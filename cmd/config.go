@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/config/profiles"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manages the configuration of minio-link",
+	Long: `Manages the configuration of minio-link.
+
+Besides plain LINK_ environment variables (or a .env file passed via --config)
+minio-link reads named profiles from config.yaml in your user config directory.`,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+
+	environment.PassphrasePrompt = promptPassphrase
+}
+
+// Asks for the passphrase of the encrypted secret file, a new file asks twice
+// as a typo would lock away every stored secret
+func promptPassphrase(create bool) (string, error) {
+	// Reading it from a pipe would consume piped input (e.g. of "upload -")
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("stdin is not a terminal, set LINK_SECRET_PASSPHRASE")
+	}
+	passphrase, err := promptSecret("Passphrase of the secret file", "", "")
+	if err != nil || !create {
+		return passphrase, err
+	}
+	repeated, err := promptSecret("Repeat the passphrase of the new secret file", "", "")
+	if err != nil {
+		return "", err
+	}
+	if repeated != passphrase {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// Loads the config from the environment, the .env file at cfgPath and the
+// selected profile (--profile, then LINK_PROFILE, then the default profile),
+// environment variables always take precedence over profile values
+func loadConfig(cfgPath string) (*environment.EnvConfig, error) {
+	cfg, err := loadUnresolvedConfig(cfgPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ResolveSecrets(); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+	return cfg, nil
+}
+
+// Works like loadConfig but keeps secret references (e.g. keyring:name) as they are
+func loadUnresolvedConfig(cfgPath string) (*environment.EnvConfig, error) {
+	file, err := profiles.Load("")
+	if err != nil {
+		return nil, err
+	}
+	name := selectedProfile(file)
+	if name == "" {
+		return environment.LoadUnresolved(nil, cfgPath)
+	}
+	profile, err := file.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := environment.LoadUnresolved(profile, cfgPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Profile = name
+	return cfg, nil
+}
+
+// Returns the name of the profile to use, empty if none
+func selectedProfile(file *profiles.File) string {
+	if profileName != "" {
+		return profileName
+	}
+	if name := os.Getenv(profileEnv); name != "" {
+		return name
+	}
+	return file.DefaultProfile
+}
+
+const (
+	profileEnv string = "LINK_PROFILE"
+)
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/config/profiles"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+)
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Creates a config file interactively and checks the connection",
+	Long: `Asks for every config variable (press enter to keep the value shown in brackets),
+checks the credentials like "config validate" and writes the config file
+(only readable by you). Variables of storage and shortener backends which are
+not used are skipped unless --all is set.
+
+The config is written to the .env file given via --file or, if --profile is set,
+to that profile in the profiles config file.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		startTime := time.Now()
+
+		logsPath := cmd.Flag("logs").Value.String()
+		debug, err := cmd.Flags().GetBool("debug")
+		cobra.CheckErr(err)
+		envFile := cmd.Flag("file").Value.String()
+		all, err := cmd.Flags().GetBool("all")
+		cobra.CheckErr(err)
+		force, err := cmd.Flags().GetBool("force")
+		cobra.CheckErr(err)
+
+		if strings.Contains(logsPath, "./") {
+			exe, err := os.Executable()
+			cobra.CheckErr(err)
+
+			logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+		}
+
+		initLogger := log.NewLogger().
+			WithDirectory(logsPath).
+			WithName("init").
+			WithDebug(debug).
+			WithConsoleOutput(debug)
+
+		// Existing values are offered as defaults so init can also be used to edit a config
+		current, err := currentConfig(envFile)
+		if err != nil {
+			initLogger.Warn(fmt.Sprintf("ignoring existing config: %s", err))
+			current, err = environment.ParseProfile(nil)
+			cobra.CheckErr(err)
+		}
+
+		values, err := promptConfig(current, all)
+		if err != nil {
+			initLogger.Error(err.Error())
+			// Interactive, errors have to be visible without debug mode too
+			cobra.CheckErr(err)
+		}
+		cfg, err := environment.ParseProfile(values)
+		if err != nil {
+			initLogger.Error(err.Error())
+			cobra.CheckErr(err)
+		}
+		cfg.Profile = profileName
+
+		fmt.Fprintln(os.Stderr, "Checking config...")
+		report := &results.ConfigReport{Profile: profileName}
+		if err := cfg.ResolveSecrets(); err != nil {
+			for _, fieldErr := range environment.FieldErrors(err) {
+				report.Checks = append(report.Checks, newFieldErrorCheck(fieldErr))
+			}
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+			defer cancel()
+			report.Checks = checkConfig(ctx, logsPath, debug, cfg)
+		}
+		report.Valid = validReport(report)
+
+		if !report.Valid && !force {
+			printResult(report)
+			if !confirm("Config has errors, save anyway?") {
+				initLogger.Error("config not saved")
+				cobra.CheckErr("config not saved")
+			}
+		}
+
+		if profileName != "" {
+			report.File, err = saveProfile(profileName, values)
+		} else {
+			report.File, err = saveEnvFile(envFile, values, force)
+		}
+		if err != nil {
+			initLogger.Error(err.Error())
+			cobra.CheckErr(err)
+		}
+
+		initLogger.Info(fmt.Sprintf("Saved config to %s", report.File))
+		fmt.Fprintf(os.Stderr, "Saved config to %s\n", report.File)
+		printResult(report)
+
+		initLogger.Debug(fmt.Sprintf("took %s", time.Since(startTime).String()))
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configInitCmd)
+
+	configInitCmd.Flags().StringP("file", "f", ".env", "Sets the env file to write")
+	configInitCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	configInitCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	configInitCmd.Flags().Bool("all", false, "Asks for variables of unused backends too")
+	configInitCmd.Flags().
+		Bool("force", false, "Saves without asking, even if the file exists or checks fail")
+}
+
+// Loads the config which will be edited, the env file is only read if it exists,
+// secret references are kept so they are not written back in plaintext
+func currentConfig(envFile string) (*environment.EnvConfig, error) {
+	if profileName != "" {
+		file, err := profiles.Load("")
+		if err != nil {
+			return nil, err
+		}
+		if profile, ok := file.Profiles[profileName]; ok {
+			return environment.ParseProfile(profile)
+		}
+		return environment.ParseProfile(nil)
+	}
+	if _, err := os.Stat(envFile); err != nil {
+		return environment.LoadUnresolved(nil)
+	}
+	return environment.LoadUnresolved(nil, envFile)
+}
+
+// Asks for every variable, backend specific ones only if the backend is used (or all is set),
+// returns the answers keyed by variable name without prefix
+func promptConfig(current *environment.EnvConfig, all bool) (map[string]string, error) {
+	fields := environment.Fields()
+	values := make(map[string]string, len(fields))
+	// Storage and shortener decide which of the other variables are needed
+	ordered := make([]environment.Field, 0, len(fields))
+	for _, field := range fields {
+		if field.Key == "STORAGE" || field.Key == "SHORTENER" {
+			ordered = append(ordered, field)
+		}
+	}
+	for _, field := range fields {
+		if field.Key != "STORAGE" && field.Key != "SHORTENER" {
+			ordered = append(ordered, field)
+		}
+	}
+
+	for _, field := range ordered {
+		def := current.Value(field.Key)
+		if !all && field.Backend != "" {
+			selected, err := environment.ParseProfile(values)
+			if err != nil {
+				return nil, err
+			}
+			if !selected.Used(field) {
+				continue
+			}
+		}
+		ask := prompt
+		hint := ""
+		if field.Secret {
+			ask = promptSecret
+			if def != "" {
+				hint = "keep current"
+			}
+		}
+		if environment.IsSecretRef(def) {
+			hint = def
+		}
+		for {
+			value, err := ask(
+				fmt.Sprintf("LINK_%s (%s)", field.Key, field.Description),
+				def,
+				hint,
+			)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkValue(field.Key, value); err != nil {
+				fmt.Fprintf(os.Stderr, "  %s\n", err)
+				continue
+			}
+			values[field.Key] = value
+			break
+		}
+	}
+	return values, nil
+}
+
+// Checks if a single value can be parsed, storage and shortener also have to be supported
+// because they decide which variables are asked for next
+func checkValue(key string, value string) error {
+	cfg, err := environment.ParseProfile(map[string]string{key: value})
+	if err != nil {
+		var errs []error
+		for _, fieldErr := range environment.FieldErrors(err) {
+			errs = append(errs, fieldErr)
+		}
+		return errors.Join(errs...)
+	}
+	if key != "STORAGE" && key != "SHORTENER" {
+		return nil
+	}
+	for _, fieldErr := range cfg.Validate() {
+		if fieldErr.Key == key {
+			return fieldErr
+		}
+	}
+	return nil
+}
+
+// Writes the values into the profile, returns the path of the profiles file
+func saveProfile(name string, values map[string]string) (string, error) {
+	file, err := profiles.Load("")
+	if err != nil {
+		return "", err
+	}
+	if err := file.Add(name, values); err != nil {
+		return "", err
+	}
+	if err := file.Save(); err != nil {
+		return "", err
+	}
+	return file.Path(), nil
+}
+
+// Writes the values as LINK_ variables into an env file only readable by the current user
+func saveEnvFile(path string, values map[string]string, force bool) (string, error) {
+	if _, err := os.Stat(path); err == nil && !force {
+		if !confirm(fmt.Sprintf("%s already exists, overwrite?", path)) {
+			return "", fmt.Errorf("config not saved, %s already exists", path)
+		}
+	}
+	prefixed := make(map[string]string, len(values))
+	for key, value := range values {
+		if value != "" {
+			prefixed["LINK_"+key] = value
+		}
+	}
+	content, err := godotenv.Marshal(prefixed)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	tmp := abs + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := os.Rename(tmp, abs); err != nil {
+		return "", fmt.Errorf("failed to write config: %w", err)
+	}
+	return abs, nil
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/config/profiles"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+)
+
+var configProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "Lists, adds and removes named config profiles",
+	Long: `Named profiles hold the settings of one MinIO / shortener pair each, e.g. a
+personal, a team and a customer facing instance. Pick one per command via
+--profile or LINK_PROFILE, otherwise the default profile (if any) is used.
+Environment variables always take precedence over profile values.`,
+}
+
+var configProfilesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists all config profiles (without secrets)",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := profiles.Load("")
+		cobra.CheckErr(err)
+		printResult(newProfilesResult(file))
+	},
+}
+
+var configProfilesAddCmd = &cobra.Command{
+	Use:   "add <name> [KEY=VALUE]...",
+	Short: "Adds a config profile or updates an existing one",
+	Long: `Adds a config profile or updates an existing one.
+
+Keys are the names of the LINK_ environment variables with or without the prefix
+(e.g. minio_endpoint=minio.example.com), an empty value removes the key again.
+Use --from-env to import all LINK_ variables of an existing .env file.`,
+	Example: `  minio-link config profiles add team minio_endpoint=minio.team.example.com \
+    minio_access_key=... minio_access_secret=... minio_use_ssl=true
+  minio-link config profiles add personal --from-env ./.env --default`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fromEnv := cmd.Flag("from-env").Value.String()
+		makeDefault, err := cmd.Flags().GetBool("default")
+		cobra.CheckErr(err)
+
+		name := args[0]
+		values := make(map[string]string)
+		if fromEnv != "" {
+			imported, err := godotenv.Read(fromEnv)
+			cobra.CheckErr(err)
+			for key, value := range imported {
+				if key == profileEnv || !strings.HasPrefix(key, "LINK_") {
+					continue
+				}
+				values[key] = value
+			}
+		}
+		for _, arg := range args[1:] {
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				cobra.CheckErr(fmt.Sprintf("invalid value %q, expected KEY=VALUE", arg))
+			}
+			values[key] = value
+		}
+
+		file, err := profiles.Load("")
+		cobra.CheckErr(err)
+		cobra.CheckErr(file.Add(name, values))
+		if makeDefault {
+			cobra.CheckErr(file.SetDefault(name))
+		}
+		cobra.CheckErr(file.Save())
+
+		fmt.Fprintf(os.Stderr, "Saved profile %s to %s\n", name, file.Path())
+		printResult(newProfilesResult(file, name))
+	},
+}
+
+var configProfilesRemoveCmd = &cobra.Command{
+	Use:     "remove <name>...",
+	Aliases: []string{"rm"},
+	Short:   "Removes config profiles",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := profiles.Load("")
+		cobra.CheckErr(err)
+		for _, name := range args {
+			cobra.CheckErr(file.Remove(name))
+		}
+		cobra.CheckErr(file.Save())
+
+		fmt.Fprintf(os.Stderr, "Removed %d profile(s) from %s\n", len(args), file.Path())
+		printResult(newProfilesResult(file))
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configProfilesCmd)
+	configProfilesCmd.AddCommand(configProfilesListCmd)
+	configProfilesCmd.AddCommand(configProfilesAddCmd)
+	configProfilesCmd.AddCommand(configProfilesRemoveCmd)
+
+	configProfilesAddCmd.Flags().
+		String("from-env", "", "Imports all LINK_ variables of the given .env file")
+	configProfilesAddCmd.Flags().
+		Bool("default", false, "Uses the profile if neither --profile nor LINK_PROFILE is set")
+}
+
+// Builds the result for the given profiles (all if none given)
+func newProfilesResult(file *profiles.File, names ...string) *results.ProfilesResult {
+	if len(names) == 0 {
+		names = file.Names()
+	}
+	active := selectedProfile(file)
+	result := &results.ProfilesResult{
+		File:     file.Path(),
+		Profiles: make([]results.Profile, 0, len(names)),
+	}
+	for _, name := range names {
+		profile := results.Profile{
+			Name:    name,
+			Default: name == file.DefaultProfile,
+			Active:  name == active,
+		}
+		cfg, err := environment.ParseProfile(file.Profiles[name])
+		if err != nil {
+			profile.Error = err.Error()
+		} else {
+			profile.Storage = cfg.Storage
+			profile.StorageEndpoint = cfg.StorageEndpoint()
+			profile.Bucket = cfg.MinioBucketName
+			profile.Shortener = cfg.Shortener
+			profile.ShortenerEndpoint = cfg.ShortenerEndpoint()
+		}
+		result.Profiles = append(result.Profiles, profile)
+	}
+	return result
+}
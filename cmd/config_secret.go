@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/spf13/cobra"
+)
+
+var configSetSecretCmd = &cobra.Command{
+	Use:   "set-secret <name> [value]",
+	Short: "Stores a secret in the configured secret store",
+	Long: `Stores a secret in the store chosen via LINK_SECRET_STORE: the OS keyring
+("keyring"), an encrypted file ("file", LINK_SECRET_FILE, passphrase via
+LINK_SECRET_PASSPHRASE or asked) or the keyring with the encrypted file as
+fallback if there is no keyring available ("auto", default).
+
+The value is asked for if not given (recommended, arguments end up in your
+shell history). Afterwards use "keyring:<name>" as value of the config variable,
+e.g. LINK_MINIO_ACCESS_SECRET=keyring:minio-secret.
+
+Besides keyring: config values may also reference "file:/run/secrets/x" or
+"cmd:pass show minio" which are resolved when the config is loaded.`,
+	Example: `  minio-link config set-secret minio-secret
+  echo -n "$TOKEN" | minio-link config set-secret yourls-signature`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfgPath := cmd.Flag("config").Value.String()
+
+		// The store settings may come from env file or profile, secrets stay unresolved
+		// because the secret we are about to set may be referenced already
+		cfg, err := loadUnresolvedConfig(cfgPath)
+		cobra.CheckErr(err)
+		// Unlike the other secrets the passphrase is needed to open the store
+		cobra.CheckErr(cfg.ResolvePassphrase())
+
+		name := args[0]
+		var value string
+		if len(args) > 1 {
+			value = args[1]
+		} else {
+			value, err = promptSecret(fmt.Sprintf("Value of secret %s", name), "", "")
+			cobra.CheckErr(err)
+		}
+		if value == "" {
+			cobra.CheckErr("secret must not be empty")
+		}
+
+		store, err := cfg.OpenSecretStore()
+		cobra.CheckErr(err)
+		cobra.CheckErr(store.Set(name, value))
+
+		result := &results.SecretResult{
+			Name:      name,
+			Store:     store.Name(),
+			Reference: environment.RefKeyring + name,
+		}
+		fmt.Fprintf(os.Stderr, "Stored secret %s in %s\n", name, result.Store)
+		printResult(result)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configSetSecretCmd)
+
+	configSetSecretCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
+}
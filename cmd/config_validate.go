@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validates the config and checks the connection to storage and shortener",
+	Long: `Validates every config variable used by the configured backends and checks
+the credentials (MinIO via BucketExists / ListBuckets, YOURLS via "db-stats").
+Exits non-zero if any check fails, nothing is uploaded or changed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		startTime := time.Now()
+
+		cfgPath := cmd.Flag("config").Value.String()
+		logsPath := cmd.Flag("logs").Value.String()
+		debug, err := cmd.Flags().GetBool("debug")
+		cobra.CheckErr(err)
+
+		if strings.Contains(logsPath, "./") {
+			exe, err := os.Executable()
+			cobra.CheckErr(err)
+
+			logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+		}
+
+		validateLogger := log.NewLogger().
+			WithDirectory(logsPath).
+			WithName("validate").
+			WithDebug(debug).
+			WithConsoleOutput(debug)
+
+		report := &results.ConfigReport{}
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			for _, fieldErr := range environment.FieldErrors(err) {
+				report.Checks = append(report.Checks, newFieldErrorCheck(fieldErr))
+			}
+		} else {
+			validateLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
+			report.Profile = cfg.Profile
+
+			ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+			defer cancel()
+			report.Checks = checkConfig(ctx, logsPath, debug, cfg)
+		}
+		report.Valid = validReport(report)
+
+		printResult(report)
+
+		validateLogger.Debug(fmt.Sprintf("took %s", time.Since(startTime).String()))
+
+		if !report.Valid {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+
+	configValidateCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
+	configValidateCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	configValidateCmd.Flags().
+		BoolP("debug", "d", false, "Sets the debug mode for our application")
+}
+
+// Checks every variable used by the configured backends and,
+// if they are all valid, the connection to storage and shortener
+func checkConfig(
+	ctx context.Context,
+	dir string,
+	debug bool,
+	cfg *environment.EnvConfig,
+) []results.ConfigCheck {
+	fieldErrs := make(map[string][]string)
+	var checks []results.ConfigCheck
+	for _, fieldErr := range cfg.Validate() {
+		if fieldErr.Key == "" {
+			checks = append(checks, newFieldErrorCheck(fieldErr))
+			continue
+		}
+		fieldErrs[fieldErr.Key] = append(fieldErrs[fieldErr.Key], fieldErr.Message)
+	}
+	for _, field := range environment.Fields() {
+		if !cfg.Used(field) {
+			continue
+		}
+		check := results.ConfigCheck{
+			Key:    "LINK_" + field.Key,
+			Value:  displayValue(field, cfg.Value(field.Key)),
+			Status: results.CheckOK,
+		}
+		if msgs, ok := fieldErrs[field.Key]; ok {
+			check.Status = results.CheckError
+			check.Message = strings.Join(msgs, "; ")
+		}
+		checks = append(checks, check)
+	}
+
+	storageCheck := results.ConfigCheck{Key: "storage connection", Value: cfg.StorageEndpoint()}
+	shortenerCheck := results.ConfigCheck{
+		Key:   "shortener connection",
+		Value: cfg.ShortenerEndpoint(),
+	}
+	if len(fieldErrs) > 0 {
+		storageCheck.Status = results.CheckSkipped
+		storageCheck.Message = "fix the errors above first"
+		shortenerCheck.Status = results.CheckSkipped
+		shortenerCheck.Message = "fix the errors above first"
+		return append(checks, storageCheck, shortenerCheck)
+	}
+
+	objectStore, err := newObjectStore(dir, debug, cfg)
+	if err != nil {
+		storageCheck.Status, storageCheck.Message = results.CheckError, err.Error()
+	} else if checker, ok := objectStore.(storage.Checker); ok {
+		storageCheck.Status, storageCheck.Message = connectionStatus(
+			checker.CheckConnection(ctx),
+		)
+	} else {
+		storageCheck.Status, storageCheck.Message = results.CheckSkipped, "not supported"
+	}
+
+	linkShortener, err := newShortener(dir, debug, cfg)
+	if err != nil {
+		shortenerCheck.Status, shortenerCheck.Message = results.CheckError, err.Error()
+	} else if checker, ok := linkShortener.(shortener.Checker); ok {
+		shortenerCheck.Status, shortenerCheck.Message = connectionStatus(
+			checker.CheckConnection(ctx),
+		)
+	} else {
+		shortenerCheck.Status, shortenerCheck.Message = results.CheckSkipped, "no shortener used"
+	}
+
+	return append(checks, storageCheck, shortenerCheck)
+}
+
+func connectionStatus(summary string, err error) (string, string) {
+	if err != nil {
+		return results.CheckError, err.Error()
+	}
+	return results.CheckOK, summary
+}
+
+func newFieldErrorCheck(fieldErr environment.FieldError) results.ConfigCheck {
+	key := "config"
+	if fieldErr.Key != "" {
+		key = "LINK_" + fieldErr.Key
+	}
+	return results.ConfigCheck{Key: key, Status: results.CheckError, Message: fieldErr.Message}
+}
+
+func validReport(report *results.ConfigReport) bool {
+	for _, check := range report.Checks {
+		if check.Status == results.CheckError {
+			return false
+		}
+	}
+	return true
+}
+
+// Hides secret values, only shows whether they are set
+func displayValue(field environment.Field, value string) string {
+	if !field.Secret {
+		return value
+	}
+	if value == "" {
+		return ""
+	}
+	return "(set)"
+}
+
+const (
+	// Timeout for all connection checks of a single command
+	checkTimeout time.Duration = 30 * time.Second
+)
@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete [short link | object key]...",
+	Short: "Deletes uploaded files from MinIO together with their short links",
+	Long: `Deletes uploaded files from MinIO together with their short links.
+
+Targets can be given as arguments, read from a file (one per line, "-" for stdin)
+via --from-file or taken from the links shown by the list command via --from-list.
+--from-list requires at least one of the list filters --older-than, --expired and
+--missing, it only considers the newest --limit links.
+With YOURLS deleting short links requires the API delete plugin ("delete" action).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		startTime := time.Now()
+
+		cfgPath := cmd.Flag("config").Value.String()
+		logsPath := cmd.Flag("logs").Value.String()
+		debug, err := cmd.Flags().GetBool("debug")
+		cobra.CheckErr(err)
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		cobra.CheckErr(err)
+		yes, err := cmd.Flags().GetBool("yes")
+		cobra.CheckErr(err)
+		fromFile := cmd.Flag("from-file").Value.String()
+		fromList, err := cmd.Flags().GetBool("from-list")
+		cobra.CheckErr(err)
+		limit, err := cmd.Flags().GetInt("limit")
+		cobra.CheckErr(err)
+		filter, err := parseListFilter(cmd)
+		cobra.CheckErr(err)
+
+		if strings.Contains(logsPath, "./") {
+			exe, err := os.Executable()
+			cobra.CheckErr(err)
+
+			logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+		}
+
+		deleteLogger := log.NewLogger().
+			WithDirectory(logsPath).
+			WithName("delete").
+			WithDebug(debug).
+			WithConsoleOutput(debug)
+
+		if fromFile == "-" && !yes && !dryRun {
+			deleteLogger.Error("reading targets from stdin requires --yes or --dry-run")
+			os.Exit(1)
+		}
+
+		if fromList && filter.isZero() {
+			deleteLogger.Error("--from-list requires --older-than, --expired or --missing")
+			os.Exit(1)
+		}
+		if !fromList && !filter.isZero() {
+			deleteLogger.Error("--older-than, --expired and --missing require --from-list")
+			os.Exit(1)
+		}
+
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			deleteLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		deleteLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
+
+		if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			deleteLogger.Warn("storage not using SSL / TLS (INSECURE)")
+		}
+
+		if endpoint := cfg.ShortenerEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			deleteLogger.Warn("shortener not using SSL / TLS (INSECURE)")
+		}
+
+		stopChan := make(chan bool, 1)
+		cancelChannel := make(chan os.Signal, 1)
+		signal.Notify(cancelChannel, os.Interrupt, syscall.SIGTERM)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			select {
+			case sig := <-cancelChannel:
+				deleteLogger.Debug(fmt.Sprintf("received sys signal: %s", sig.String()))
+				cancel()
+				return
+			case <-stopChan:
+				deleteLogger.Debug("received stop signal")
+				return
+			}
+		}()
+
+		objectStore, err := newObjectStore(logsPath, debug, cfg)
+		if err != nil {
+			deleteLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		linkShortener, err := newShortener(logsPath, debug, cfg)
+		if err != nil {
+			deleteLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		historyStore, err := history.NewStore("")
+		if err != nil {
+			deleteLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		inputs := args
+		if fromFile != "" {
+			lines, err := readTargetsFile(fromFile)
+			if err != nil {
+				deleteLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			inputs = append(inputs, lines...)
+		}
+		if fromList {
+			listed, err := listLinks(ctx, objectStore, linkShortener, limit, deleteLogger)
+			if err != nil {
+				deleteLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			for _, entry := range filter.apply(listed.Entries, time.Now()) {
+				inputs = append(inputs, entry.ShortURL)
+			}
+		}
+		if len(inputs) == 0 {
+			deleteLogger.Error("nothing to delete, pass links / keys, --from-file or --from-list")
+			os.Exit(1)
+		}
+
+		// Inputs which can not be resolved are reported as failed, the others are deleted
+		targets := make([]linkTarget, 0, len(inputs))
+		var unresolved []results.Deletion
+		for _, input := range inputs {
+			target, err := resolveLinkTarget(ctx, objectStore, linkShortener, historyStore, input)
+			if err != nil {
+				deleteLogger.Error(fmt.Sprintf("failed to resolve %s: %s", input, err))
+				unresolved = append(unresolved, results.Deletion{
+					Object: input,
+					Error:  fmt.Sprintf("failed to resolve: %s", err),
+				})
+				continue
+			}
+			targets = append(targets, target)
+		}
+
+		if dryRun || len(targets) == 0 {
+			res := &results.DeleteResult{
+				DryRun:  dryRun,
+				Deleted: make([]results.Deletion, 0, len(inputs)),
+			}
+			for _, target := range targets {
+				res.Deleted = append(res.Deleted, newDeletion(target))
+			}
+			res.Deleted = append(res.Deleted, unresolved...)
+			printResult(res)
+			if len(unresolved) > 0 {
+				deleteLogger.Error(fmt.Sprintf("failed to resolve %d of %d targets",
+					len(unresolved), len(inputs)))
+				os.Exit(1)
+			}
+			return
+		}
+
+		if !yes {
+			for _, target := range targets {
+				shortURL := target.shortURL
+				if shortURL == "" {
+					shortURL = "no short link"
+				}
+				fmt.Fprintf(os.Stderr, "%s/%s (%s)\n", target.bucket, target.object, shortURL)
+			}
+			question := fmt.Sprintf("Delete %d object(s) and their short links?", len(targets))
+			if !confirm(question) {
+				fmt.Fprintln(os.Stderr, "Aborted")
+				return
+			}
+		}
+
+		res, failed := deleteTargets(
+			ctx,
+			objectStore,
+			linkShortener,
+			historyStore,
+			targets,
+			deleteLogger,
+		)
+		res.Deleted = append(res.Deleted, unresolved...)
+		failed += len(unresolved)
+		printResult(res)
+
+		close(stopChan)
+		close(cancelChannel)
+		deleteLogger.Debug("closed stop and cancel channels")
+
+		if failed > 0 {
+			deleteLogger.Error(fmt.Sprintf("%d of %d deletions failed", failed, len(inputs)))
+			os.Exit(1)
+		}
+
+		deleteLogger.Info("Deleting done")
+		deleteLogger.Debug(fmt.Sprintf("took %s", time.Since(startTime).String()))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+
+	deleteCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
+	deleteCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	deleteCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	deleteCmd.Flags().Bool("dry-run", false, "Only shows what would be deleted")
+	deleteCmd.Flags().BoolP("yes", "y", false, "Skips the confirmation prompt")
+	deleteCmd.Flags().
+		StringP("from-file", "f", "", "Reads links / keys from a file (one per line, - for stdin)")
+	deleteCmd.Flags().Bool("from-list", false, "Deletes the links shown by the list command")
+	deleteCmd.Flags().IntP("limit", "i", 20, "Sets the limit for urls to fetch with --from-list")
+	deleteCmd.Flags().
+		String("older-than", "", "Only deletes listed links created longer ago (e.g. 30d)")
+	deleteCmd.Flags().Bool("expired", false, "Only deletes listed links which expired")
+	deleteCmd.Flags().Bool("missing", false, "Only deletes listed links whose file is gone")
+}
+
+// Deletes all targets, failures are logged and reported per target,
+// returns the result and the amount of failed deletions
+func deleteTargets(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	linkShortener shortener.Shortener,
+	historyStore *history.Store,
+	targets []linkTarget,
+	logger *log.Logger,
+) (*results.DeleteResult, int) {
+	res := &results.DeleteResult{Deleted: make([]results.Deletion, 0, len(targets))}
+	failed := 0
+	for _, target := range targets {
+		deletion := newDeletion(target)
+		err := deleteLink(ctx, objectStore, linkShortener, historyStore, target)
+		var lockedErr *storage.LockedError
+		if errors.As(err, &lockedErr) {
+			err = fmt.Errorf("%w: %s", err, lockHint(lockedErr.Lock))
+		}
+		if err != nil {
+			failed++
+			logger.Error(fmt.Sprintf("failed to delete %s: %s", target.object, err))
+			deletion.Error = err.Error()
+		} else {
+			deletion.Deleted = true
+		}
+		res.Deleted = append(res.Deleted, deletion)
+	}
+	return res, failed
+}
+
+// Removes the object, its short link and its history entries
+func deleteLink(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	linkShortener shortener.Shortener,
+	historyStore *history.Store,
+	target linkTarget,
+) error {
+	if err := objectStore.DeleteObject(ctx, target.bucket, target.object); err != nil {
+		return err
+	}
+	if target.shortURL != "" {
+		if err := linkShortener.DeleteURL(ctx, target.shortURL); err != nil {
+			return err
+		}
+	}
+	_, err := historyStore.Remove(target.bucket, target.object)
+	return err
+}
+
+func newDeletion(target linkTarget) results.Deletion {
+	return results.Deletion{
+		Bucket:   target.bucket,
+		Object:   target.object,
+		ShortURL: target.shortURL,
+	}
+}
+
+// Reads one target per line, empty lines and lines starting with # are skipped
+func readTargetsFile(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open targets file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	var targets []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read targets file: %w", err)
+	}
+	return targets, nil
+}
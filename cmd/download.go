@@ -2,7 +2,12 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	filepathlib "path/filepath"
@@ -11,16 +16,30 @@ import (
 	"time"
 
 	"github.com/devusSs/minio-link/internal/config/environment"
-	"github.com/devusSs/minio-link/internal/minio"
-	"github.com/devusSs/minio-link/internal/yourls"
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/crypt"
 	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/progress"
 	"github.com/spf13/cobra"
 )
 
 var downloadCmd = &cobra.Command{
-	Use:   "download [link]",
-	Short: "Downloads a file from MinIO via it's shortened YOURLS url",
-	Args:  cobra.ExactArgs(1),
+	Use:   "download <short link | url | object key>",
+	Short: "Downloads a file via it's short link, url or object key",
+	Long: `Downloads a file, the input type is detected automatically:
+
+  - short links of the configured shortener are expanded
+  - links of the configured storage (public or presigned) and object keys
+    ("bucket/key" or a key in the private bucket) are fetched with credentials
+  - any other link (e.g. of another shortener) is requested and its redirects are
+    followed, files served by other hosts are downloaded via plain HTTP`,
+	Example: `  minio-link download https://short.link/abc
+  minio-link download minio-link-private/report.pdf -o -
+  minio-link download https://example.com/files/report.pdf`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		startTime := time.Now()
 
@@ -29,7 +48,22 @@ var downloadCmd = &cobra.Command{
 		logsPath := cmd.Flag("logs").Value.String()
 		debug, err := cmd.Flags().GetBool("debug")
 		cobra.CheckErr(err)
-		filepath := cmd.Flag("filepath").Value.String()
+		progressMode, err := progress.ParseMode(cmd.Flag("progress").Value.String())
+		cobra.CheckErr(err)
+		filepath := cmd.Flag("out").Value.String()
+		if cmd.Flags().Changed("filepath") {
+			filepath = cmd.Flag("filepath").Value.String()
+		}
+		link, keyInput := splitLinkKey(link)
+		if input := cmd.Flag("key").Value.String(); input != "" {
+			keyInput = input
+		}
+		var key *crypt.Key
+		if keyInput != "" {
+			parsed, err := crypt.ParseKey(keyInput)
+			cobra.CheckErr(err)
+			key = &parsed
+		}
 
 		if strings.Contains(logsPath, "./") {
 			exe, err := os.Executable()
@@ -44,20 +78,27 @@ var downloadCmd = &cobra.Command{
 			WithDebug(debug).
 			WithConsoleOutput(debug)
 
-		cfg, err := environment.Load(cfgPath)
+		cfg, err := loadConfig(cfgPath)
 		if err != nil {
 			downloadLogger.Error(err.Error())
 			os.Exit(1)
 		}
 
+		if err := applySSEFlag(cmd, cfg); err != nil {
+			downloadLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
 		downloadLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
 
-		if !cfg.MinioUseSSL {
-			downloadLogger.Warn("minio not using SSL / TLS (INSECURE)")
+		if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			downloadLogger.Warn("storage not using SSL / TLS (INSECURE)")
 		}
 
-		if !strings.Contains(cfg.YourlsEndpoint, "https://") {
-			downloadLogger.Warn("yourls not using SSL / TLS (INSECURE)")
+		if endpoint := cfg.ShortenerEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			downloadLogger.Warn("shortener not using SSL / TLS (INSECURE)")
 		}
 
 		stopChan := make(chan bool, 1)
@@ -79,26 +120,85 @@ var downloadCmd = &cobra.Command{
 			}
 		}()
 
-		yourlsClient := yourls.NewClient(logsPath, debug, cfg)
-		originalURL, err := yourlsClient.ExpandURL(ctx, link)
+		linkShortener, err := newShortener(logsPath, debug, cfg)
 		if err != nil {
 			downloadLogger.Error(err.Error())
 			os.Exit(1)
 		}
 
-		downloadLogger.Debug(fmt.Sprintf("original url: %s", originalURL))
-
-		minioClient, err := minio.NewClient(logsPath, debug, cfg)
+		objectStore, err := newObjectStore(logsPath, debug, cfg)
 		if err != nil {
 			downloadLogger.Error(err.Error())
 			os.Exit(1)
 		}
+		objectStore.SetProgress(progressMode)
 
-		if err := minioClient.DownloadFile(ctx, originalURL, filepath); err != nil {
+		originalURL, resp, err := resolveDownload(ctx, cfg, linkShortener, link)
+		if err != nil {
 			downloadLogger.Error(err.Error())
 			os.Exit(1)
 		}
 
+		downloadLogger.Debug(fmt.Sprintf("original url: %s", originalURL))
+
+		// The file itself goes to stdout when streaming, so there is no result to render
+		if filepath == "-" {
+			download := func(w io.Writer) error {
+				return objectStore.DownloadToWriter(ctx, originalURL, w)
+			}
+			if resp != nil {
+				download = func(w io.Writer) error {
+					return storage.StreamResponse(resp, w, progressMode)
+				}
+			}
+			if key != nil {
+				err = downloadDecrypted(download, *key, os.Stdout)
+			} else {
+				err = downloadPlain(download, os.Stdout)
+			}
+			if err != nil {
+				downloadLogger.Error(err.Error())
+				os.Exit(1)
+			}
+		} else {
+			var download *storage.Download
+			if resp != nil {
+				download, err = storage.DownloadResponse(resp, filepath, progressMode)
+			} else {
+				download, err = objectStore.DownloadFile(ctx, originalURL, filepath)
+			}
+			if err != nil {
+				downloadLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			// Files of other hosts were never uploaded by us, there is nothing to verify against
+			if !download.Verified && resp == nil {
+				if err := verifyDownload(download); err != nil {
+					downloadLogger.Error(err.Error())
+					os.Exit(1)
+				}
+			}
+			decrypted, err := decryptDownload(download, key)
+			if err != nil {
+				downloadLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			if key != nil && !decrypted {
+				downloadLogger.Warn("object is not encrypted, ignoring key")
+			}
+			printResult(&results.DownloadResult{
+				Link:      link,
+				MinioURL:  originalURL,
+				Bucket:    download.Bucket,
+				Object:    download.Object,
+				Path:      download.Path,
+				Size:      download.Size,
+				Checksum:  download.Checksum,
+				Verified:  download.Verified,
+				Decrypted: decrypted,
+			})
+		}
+
 		close(stopChan)
 		close(cancelChannel)
 		downloadLogger.Debug("closed stop and cancel channels")
@@ -114,6 +214,259 @@ func init() {
 	downloadCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
 	downloadCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
 	downloadCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	downloadCmd.Flags().
+		String("progress", "auto", "Sets the progress output (auto, bar, json, none)")
 	downloadCmd.Flags().
 		StringP("filepath", "f", "", "Sets a custom filepath for the downloaded file")
+	downloadCmd.Flags().
+		StringP("out", "o", "", "Sets the output path, \"-\" streams the file to stdout")
+	cobra.CheckErr(downloadCmd.Flags().MarkDeprecated("filepath", "use --out instead"))
+	downloadCmd.MarkFlagsMutuallyExclusive("filepath", "out")
+	downloadCmd.Flags().
+		StringP("key", "k", "", "Sets the key of an encrypted upload if it is not part of the link")
+	downloadCmd.Flags().
+		String("sse", "", "Sets the SSE-C key file (c:<keyfile>) of server side encrypted objects")
+}
+
+// Verifies downloads of backends without stored checksums against the history,
+// mismatching files are quarantined
+func verifyDownload(download *storage.Download) error {
+	historyStore, err := history.NewStore("")
+	if err != nil {
+		return err
+	}
+	expected := historyChecksum(historyStore, download.Bucket, download.Object)
+	if expected == "" {
+		return nil
+	}
+	if expected != download.Checksum {
+		checksumErr := &storage.ChecksumError{Expected: expected, Actual: download.Checksum}
+		checksumErr.Quarantined, err = storage.QuarantineFile(download.Path)
+		if err != nil {
+			return errors.Join(checksumErr, err)
+		}
+		return checksumErr
+	}
+	download.Verified = true
+	return nil
+}
+
+// Decrypts an encrypted download in place, encrypted files are detected by their
+// metadata or header, returns whether the file was decrypted
+func decryptDownload(download *storage.Download, key *crypt.Key) (bool, error) {
+	encrypted := download.Encryption != ""
+	if !encrypted {
+		var err error
+		if encrypted, err = crypt.IsEncryptedFile(download.Path); err != nil {
+			return false, err
+		}
+	}
+	if !encrypted {
+		return false, nil
+	}
+	if key == nil {
+		// Ciphertext is of no use, never leave it behind looking like the real file
+		_ = os.Remove(download.Path)
+		return false, errEncryptedDownload
+	}
+
+	src, err := os.Open(download.Path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open downloaded file: %w", err)
+	}
+	defer src.Close()
+	tmpPath := download.Path + ".part"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to create file: %w", err)
+	}
+	dec, err := crypt.NewDecrypter(dst, *key)
+	if err == nil {
+		_, err = io.Copy(dec, src)
+	}
+	if err == nil {
+		err = dec.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		_ = os.Remove(download.Path)
+		return false, err
+	}
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat decrypted file: %w", err)
+	}
+	if err := os.Rename(tmpPath, download.Path); err != nil {
+		return false, fmt.Errorf("failed to move decrypted file into place: %w", err)
+	}
+	download.Size = info.Size()
+	return true, nil
+}
+
+// Streams the decrypted file written by download into w
+func downloadDecrypted(download func(w io.Writer) error, key crypt.Key, w io.Writer) error {
+	dec, err := crypt.NewDecrypter(w, key)
+	if err != nil {
+		return err
+	}
+	if err := download(dec); err != nil {
+		return err
+	}
+	return dec.Close()
+}
+
+// Streams the file written by download into w, fails before writing anything
+// if it is encrypted
+func downloadPlain(download func(w io.Writer) error, w io.Writer) error {
+	pw := &plaintextWriter{w: w}
+	if err := download(pw); err != nil {
+		return err
+	}
+	return pw.Flush()
+}
+
+// Holds back the first bytes until it is known that they are no encryption header
+type plaintextWriter struct {
+	w       io.Writer
+	head    []byte
+	checked bool
+}
+
+func (p *plaintextWriter) Write(b []byte) (int, error) {
+	if p.checked {
+		return p.w.Write(b)
+	}
+	p.head = append(p.head, b...)
+	if len(p.head) < crypt.HeaderSize {
+		return len(b), nil
+	}
+	if crypt.IsEncrypted(p.head) {
+		return 0, errEncryptedDownload
+	}
+	if err := p.Flush(); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Writes the held back bytes of files shorter than the header
+func (p *plaintextWriter) Flush() error {
+	p.checked = true
+	if len(p.head) == 0 {
+		return nil
+	}
+	_, err := p.w.Write(p.head)
+	p.head = nil
+	return err
+}
+
+// Resolves the input of the download command. Object keys and links of the configured
+// storage are returned as is for the object store, short links of the configured
+// shortener are expanded. Any other link is requested and its redirects are followed
+// until they point to the storage or the shortener, if it serves a file instead the
+// response is returned to save its body.
+func resolveDownload(
+	ctx context.Context,
+	cfg *environment.EnvConfig,
+	linkShortener shortener.Shortener,
+	input string,
+) (string, *http.Response, error) {
+	storageHost := storageLinkHost(cfg)
+	shortenerHost := linkHost(cfg.ShortenerEndpoint())
+	// Bodies of large files may take long, only waiting for a response is limited
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = downloadResponseTimeout
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxDownloadRedirects {
+				return fmt.Errorf("stopped after %d redirects", len(via))
+			}
+			if host := linkHost(req.URL.String()); host != "" &&
+				(host == storageHost || host == shortenerHost) {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+	for range maxDownloadRedirects {
+		if !storage.IsLink(input) {
+			return input, nil, nil
+		}
+		host := linkHost(input)
+		if host != "" && host == storageHost {
+			return input, nil, nil
+		}
+		if host != "" && host == shortenerHost {
+			longURL, err := linkShortener.ExpandURL(ctx, input)
+			if err != nil {
+				return "", nil, err
+			}
+			input = longURL
+			continue
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, input, nil)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to request %s: %w", input, err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			// Login pages, error pages or file hosters' landing pages instead of a file
+			if mediaType, _, _ := mime.ParseMediaType(
+				resp.Header.Get("Content-Type"),
+			); mediaType == "text/html" {
+				resp.Body.Close()
+				return "", nil, fmt.Errorf(
+					"%s is a web page (text/html) and no file, open it in a browser",
+					input,
+				)
+			}
+			return resp.Request.URL.String(), resp, nil
+		}
+		location, err := resp.Location()
+		resp.Body.Close()
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || err != nil {
+			return "", nil, fmt.Errorf("failed to request %s: %s", input, resp.Status)
+		}
+		input = location.String()
+	}
+	return "", nil, fmt.Errorf("failed to resolve %s: too many redirects", input)
+}
+
+// Returns the host share links of the configured storage point to,
+// empty if it has none
+func storageLinkHost(cfg *environment.EnvConfig) string {
+	switch strings.ToLower(cfg.Storage) {
+	case storage.BackendFilesystem:
+		return linkHost(cfg.FilesystemBaseURL)
+	case storage.BackendWebDAV:
+		return linkHost(cfg.WebDAVBaseURL)
+	}
+	return linkHost(cfg.StorageEndpoint())
+}
+
+// Returns the lower case host (including the port) of link, empty if it has none
+func linkHost(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
 }
+
+// Returned for encrypted objects downloaded without a key
+var errEncryptedDownload = errors.New(
+	"object is encrypted, use the link including its #key or pass --key",
+)
+
+const (
+	maxDownloadRedirects    int           = 10
+	downloadResponseTimeout time.Duration = 30 * time.Second
+)
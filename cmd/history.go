@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/clip"
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/timeparse"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [search]",
+	Short: "Lists, searches and re-copies links of previous uploads",
+	Long: `Lists uploads recorded in the local history file (newest first).
+The optional search term is matched against file names, object names and links.
+Use --copy with the number shown in the list to copy that link to the clipboard again.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		startTime := time.Now()
+
+		logsPath := cmd.Flag("logs").Value.String()
+		debug, err := cmd.Flags().GetBool("debug")
+		cobra.CheckErr(err)
+		limit, err := cmd.Flags().GetInt("limit")
+		cobra.CheckErr(err)
+		copyIndex, err := cmd.Flags().GetInt("copy")
+		cobra.CheckErr(err)
+		historyFile := cmd.Flag("file").Value.String()
+
+		filter := history.Filter{Limit: limit}
+		if len(args) > 0 {
+			filter.Search = args[0]
+		}
+		if since := cmd.Flag("since").Value.String(); since != "" {
+			filter.Since, err = parseHistoryTime(since, false)
+			cobra.CheckErr(err)
+		}
+		if until := cmd.Flag("until").Value.String(); until != "" {
+			filter.Until, err = parseHistoryTime(until, true)
+			cobra.CheckErr(err)
+		}
+
+		if strings.Contains(logsPath, "./") {
+			exe, err := os.Executable()
+			cobra.CheckErr(err)
+
+			logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+		}
+
+		historyLogger := log.NewLogger().
+			WithDirectory(logsPath).
+			WithName("history").
+			WithDebug(debug).
+			WithConsoleOutput(debug)
+
+		store, err := history.NewStore(historyFile)
+		if err != nil {
+			historyLogger.Error(err.Error())
+			os.Exit(1)
+		}
+		historyLogger.Debug(fmt.Sprintf("using history file: %s", store.Path()))
+
+		entries, err := store.List(filter)
+		if err != nil {
+			historyLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		if copyIndex > 0 {
+			if copyIndex > len(entries) {
+				historyLogger.Error(
+					fmt.Sprintf("invalid entry %d, only %d entries found", copyIndex, len(entries)),
+				)
+				os.Exit(1)
+			}
+			entry := entries[copyIndex-1]
+			if err := clip.CopyToClipboard(entry.Link()); err != nil {
+				historyLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			historyLogger.Info(fmt.Sprintf("Copied link of %s to clipboard", entry.FileName))
+			printResult(&results.HistoryResult{
+				Entries: []history.Entry{entry},
+				Copied:  entry.Link(),
+			})
+			return
+		}
+
+		printResult(&results.HistoryResult{Entries: entries})
+
+		historyLogger.Debug(fmt.Sprintf("took %s", time.Since(startTime).String()))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	historyCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	historyCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	historyCmd.Flags().
+		StringP("file", "f", "", "Sets a custom history file (default in user data directory)")
+	historyCmd.Flags().IntP("limit", "i", 20, "Sets the limit for entries to show (0 = all)")
+	historyCmd.Flags().
+		String("since", "", "Only shows uploads since a date (2006-01-02) or duration ago (7d)")
+	historyCmd.Flags().
+		String("until", "", "Only shows uploads until a date (2006-01-02) or duration ago (7d)")
+	historyCmd.Flags().
+		Int("copy", 0, "Copies the link of the given entry number to the clipboard")
+}
+
+// Parses a date or a duration which is interpreted as "ago",
+// a plain date used as upper bound includes the whole day
+func parseHistoryTime(input string, upper bool) (time.Time, error) {
+	now := time.Now()
+	if d, err := timeparse.ParseDuration(input); err == nil {
+		return now.Add(-d), nil
+	}
+	t, dateOnly, err := timeparse.ParseTime(input, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper && dateOnly {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/progress"
+	"github.com/spf13/cobra"
+)
+
+var inboxCmd = &cobra.Command{
+	Use:   "inbox [prefix]",
+	Short: "Lists and downloads files others uploaded via requested links",
+	Long: `Lists the files uploaded via links created by request-upload (newest first),
+optionally only those below the given prefix (e.g. "acme").
+
+With --download every listed file is downloaded, by default to
+"./files/inbox/<prefix>/<name>" or to "<out>/<prefix>/<name>" if --out is set.`,
+	Example: `  minio-link inbox
+  minio-link inbox acme --download --out ~/Downloads/acme`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		startTime := time.Now()
+
+		cfgPath := cmd.Flag("config").Value.String()
+		logsPath := cmd.Flag("logs").Value.String()
+		debug, err := cmd.Flags().GetBool("debug")
+		cobra.CheckErr(err)
+		download, err := cmd.Flags().GetBool("download")
+		cobra.CheckErr(err)
+		out := cmd.Flag("out").Value.String()
+		progressMode, err := progress.ParseMode(cmd.Flag("progress").Value.String())
+		cobra.CheckErr(err)
+		var prefix string
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+
+		if strings.Contains(logsPath, "./") {
+			exe, err := os.Executable()
+			cobra.CheckErr(err)
+
+			logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+		}
+
+		inboxLogger := log.NewLogger().
+			WithDirectory(logsPath).
+			WithName("inbox").
+			WithDebug(debug).
+			WithConsoleOutput(debug)
+
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			inboxLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		inboxLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
+
+		if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			inboxLogger.Warn("storage not using SSL / TLS (INSECURE)")
+		}
+
+		stopChan := make(chan bool, 1)
+		cancelChannel := make(chan os.Signal, 1)
+		signal.Notify(cancelChannel, os.Interrupt, syscall.SIGTERM)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			select {
+			case sig := <-cancelChannel:
+				inboxLogger.Debug(fmt.Sprintf("received sys signal: %s", sig.String()))
+				cancel()
+				return
+			case <-stopChan:
+				inboxLogger.Debug("received stop signal")
+				return
+			}
+		}()
+
+		objectStore, err := newObjectStore(logsPath, debug, cfg)
+		if err != nil {
+			inboxLogger.Error(err.Error())
+			os.Exit(1)
+		}
+		objectStore.SetProgress(progressMode)
+		requester, ok := objectStore.(storage.Requester)
+		if !ok {
+			inboxLogger.Error(fmt.Errorf("upload requests: %w", storage.ErrUnsupported).Error())
+			os.Exit(1)
+		}
+
+		objects, err := requester.ListInbox(ctx, prefix)
+		if err != nil {
+			inboxLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		res := &results.InboxResult{Entries: make([]results.InboxEntry, 0, len(objects))}
+		failed := 0
+		for _, object := range objects {
+			entry := results.InboxEntry{
+				Bucket:   object.Bucket,
+				Object:   object.Object,
+				Size:     object.Size,
+				Uploaded: object.LastModified,
+				MinioURL: object.URL,
+			}
+			if download {
+				entry.Path, err = downloadInboxObject(ctx, objectStore, object, out)
+				if err != nil {
+					failed++
+					inboxLogger.Error(fmt.Sprintf("failed to download %s: %s", object.Object, err))
+					entry.Error = err.Error()
+				}
+			}
+			res.Entries = append(res.Entries, entry)
+		}
+		printResult(res)
+
+		close(stopChan)
+		close(cancelChannel)
+		inboxLogger.Debug("closed stop and cancel channels")
+
+		if failed > 0 {
+			inboxLogger.Error(fmt.Sprintf("%d of %d downloads failed", failed, len(objects)))
+			os.Exit(1)
+		}
+
+		inboxLogger.Info("Inbox done")
+		inboxLogger.Debug(fmt.Sprintf("took %s", time.Since(startTime).String()))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inboxCmd)
+
+	inboxCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
+	inboxCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	inboxCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	inboxCmd.Flags().Bool("download", false, "Downloads every listed file")
+	inboxCmd.Flags().
+		StringP("out", "o", "", "Sets the directory to download files to (default ./files/inbox)")
+	inboxCmd.Flags().
+		String("progress", "auto", "Sets the progress output (auto, bar, json, none)")
+}
+
+// Downloads a file of the inbox below out (or the default download path),
+// returns the path it was written to
+func downloadInboxObject(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	object storage.InboxObject,
+	out string,
+) (string, error) {
+	var target string
+	if out != "" {
+		// Names are chosen by the uploader and must not escape out
+		var err error
+		target, err = storage.LocalPath(out, strings.TrimPrefix(object.Object, storage.InboxPrefix))
+		if err != nil {
+			return "", err
+		}
+	}
+	download, err := objectStore.DownloadFile(ctx, object.URL, target)
+	if err != nil {
+		return "", err
+	}
+	return download.Path, nil
+}
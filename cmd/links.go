@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/crypt"
+)
+
+// An uploaded object and its short link (may be empty)
+type linkTarget struct {
+	bucket   string
+	object   string
+	shortURL string
+}
+
+// Resolves a short link, minio url or object key to a link target,
+// short links for object keys are looked up in the history
+func resolveLinkTarget(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	linkShortener shortener.Shortener,
+	historyStore *history.Store,
+	input string,
+) (linkTarget, error) {
+	var target linkTarget
+	// Fragments only carry keys of encrypted uploads
+	input, _ = splitLinkKey(input)
+	ref := input
+	if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
+		if longURL, err := linkShortener.ExpandURL(ctx, input); err == nil && longURL != "" {
+			target.shortURL = input
+			ref = longURL
+		}
+	}
+
+	bucket, object, err := objectStore.ResolveObject(ref)
+	if err != nil {
+		return target, err
+	}
+	target.bucket = bucket
+	target.object = object
+
+	if target.shortURL == "" {
+		entries, err := historyStore.List(history.Filter{})
+		if err != nil {
+			return target, err
+		}
+		for _, entry := range entries {
+			if entry.Bucket == bucket && entry.Object == object && entry.ShortURL != "" {
+				target.shortURL = entry.ShortURL
+				break
+			}
+		}
+	}
+	return target, nil
+}
+
+// Appends the key of an encrypted upload to the link as fragment,
+// fragments are never sent to servers
+func linkWithKey(link string, key crypt.Key) string {
+	return link + "#" + key.String()
+}
+
+// Splits a link into the link itself and the key in its fragment (if any)
+func splitLinkKey(link string) (string, string) {
+	if !storage.IsLink(link) {
+		return link, ""
+	}
+	link, key, _ := strings.Cut(link, "#")
+	return link, key
+}
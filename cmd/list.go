@@ -6,14 +6,16 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/devusSs/minio-link/internal/config/environment"
-	"github.com/devusSs/minio-link/internal/minio"
-	"github.com/devusSs/minio-link/internal/yourls"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/internal/storage"
 	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/timeparse"
 	"github.com/spf13/cobra"
 )
 
@@ -29,6 +31,8 @@ var listCmd = &cobra.Command{
 		cobra.CheckErr(err)
 		limit, err := cmd.Flags().GetInt("limit")
 		cobra.CheckErr(err)
+		filter, err := parseListFilter(cmd)
+		cobra.CheckErr(err)
 
 		if strings.Contains(logsPath, "./") {
 			exe, err := os.Executable()
@@ -43,7 +47,7 @@ var listCmd = &cobra.Command{
 			WithDebug(debug).
 			WithConsoleOutput(debug)
 
-		cfg, err := environment.Load(cfgPath)
+		cfg, err := loadConfig(cfgPath)
 		if err != nil {
 			listLogger.Error(err.Error())
 			os.Exit(1)
@@ -51,12 +55,14 @@ var listCmd = &cobra.Command{
 
 		listLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
 
-		if !cfg.MinioUseSSL {
-			listLogger.Warn("minio not using SSL / TLS (INSECURE)")
+		if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			listLogger.Warn("storage not using SSL / TLS (INSECURE)")
 		}
 
-		if !strings.Contains(cfg.YourlsEndpoint, "https://") {
-			listLogger.Warn("yourls not using SSL / TLS (INSECURE)")
+		if endpoint := cfg.ShortenerEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			listLogger.Warn("shortener not using SSL / TLS (INSECURE)")
 		}
 
 		stopChan := make(chan bool, 1)
@@ -78,29 +84,25 @@ var listCmd = &cobra.Command{
 			}
 		}()
 
-		yClient := yourls.NewClient(logsPath, debug, cfg)
-		yURLs, err := yClient.GetSavedURLs(ctx, limit)
+		linkShortener, err := newShortener(logsPath, debug, cfg)
 		if err != nil {
 			listLogger.Error(err.Error())
 			os.Exit(1)
 		}
 
-		minioClient, err := minio.NewClient(logsPath, debug, cfg)
+		objectStore, err := newObjectStore(logsPath, debug, cfg)
 		if err != nil {
 			listLogger.Error(err.Error())
 			os.Exit(1)
 		}
 
-		links := make([]string, 0, len(yURLs))
-		for _, yURL := range yURLs {
-			links = append(links, yURL)
-		}
-
-		err = minioClient.GetObjects(ctx, links)
+		res, err := listLinks(ctx, objectStore, linkShortener, limit, listLogger)
 		if err != nil {
 			listLogger.Error(err.Error())
 			os.Exit(1)
 		}
+		res.Entries = filter.apply(res.Entries, time.Now())
+		printResult(res)
 
 		close(stopChan)
 		close(cancelChannel)
@@ -118,4 +120,111 @@ func init() {
 	listCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
 	listCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
 	listCmd.Flags().IntP("limit", "i", 20, "Sets the limit for urls to fetch")
+	listCmd.Flags().String("older-than", "", "Only shows links created longer ago (e.g. 30d)")
+	listCmd.Flags().Bool("expired", false, "Only shows links whose share link or file expired")
+	listCmd.Flags().Bool("missing", false, "Only shows links whose file does not exist anymore")
+}
+
+// Narrows down the links fetched by listLinks, the zero value keeps every link
+type listFilter struct {
+	olderThan time.Duration
+	expired   bool
+	missing   bool
+}
+
+// Reads --older-than, --expired and --missing
+func parseListFilter(cmd *cobra.Command) (listFilter, error) {
+	var filter listFilter
+	var err error
+	if input := cmd.Flag("older-than").Value.String(); input != "" {
+		filter.olderThan, err = timeparse.ParseDuration(input)
+		if err != nil {
+			return listFilter{}, err
+		}
+	}
+	if filter.expired, err = cmd.Flags().GetBool("expired"); err != nil {
+		return listFilter{}, err
+	}
+	if filter.missing, err = cmd.Flags().GetBool("missing"); err != nil {
+		return listFilter{}, err
+	}
+	return filter, nil
+}
+
+func (f listFilter) isZero() bool {
+	return f == listFilter{}
+}
+
+// Returns the entries matching every set condition
+func (f listFilter) apply(entries []results.ListEntry, now time.Time) []results.ListEntry {
+	return slices.DeleteFunc(entries, func(entry results.ListEntry) bool {
+		return !f.matches(&entry, now)
+	})
+}
+
+func (f listFilter) matches(entry *results.ListEntry, now time.Time) bool {
+	if f.olderThan > 0 && !entry.Created.Before(now.Add(-f.olderThan)) {
+		return false
+	}
+	if f.expired && !expiredBefore(entry.LinkExpires, now) &&
+		!expiredBefore(entry.DeleteAt, now) {
+		return false
+	}
+	// Objects which could not be checked are never taken as missing
+	if f.missing && (entry.Exists || entry.Error != "") {
+		return false
+	}
+	return true
+}
+
+func expiredBefore(t *time.Time, now time.Time) bool {
+	return t != nil && t.Before(now)
+}
+
+// Fetches the newest short links and checks if the objects behind them still exist
+func listLinks(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	linkShortener shortener.Shortener,
+	limit int,
+	logger *log.Logger,
+) (*results.ListResult, error) {
+	savedLinks, err := linkShortener.ListURLs(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]string, 0, len(savedLinks))
+	for _, savedLink := range savedLinks {
+		links = append(links, savedLink.URL)
+	}
+
+	statuses := objectStore.StatObjects(ctx, links)
+	res := &results.ListResult{Entries: make([]results.ListEntry, 0, len(statuses))}
+	for i, status := range statuses {
+		entry := results.ListEntry{
+			ShortURL:     savedLinks[i].ShortURL,
+			MinioURL:     status.Link,
+			Bucket:       status.Bucket,
+			Object:       status.Object,
+			Exists:       status.Exists,
+			Size:         status.Size,
+			Created:      savedLinks[i].Created,
+			Clicks:       savedLinks[i].Clicks,
+			LastModified: results.TimeOrNil(status.LastModified),
+			LinkExpires:  results.TimeOrNil(status.LinkExpires),
+			DeleteAt:     results.TimeOrNil(status.DeleteAt),
+			Lock: results.NewLock(
+				status.Lock.Mode,
+				status.Lock.RetainUntil,
+				status.Lock.LegalHold,
+			),
+		}
+		if status.Err != nil {
+			logger.Warn(fmt.Sprintf("failed to check %s: %s", status.Link, status.Err))
+			entry.Error = status.Err.Error()
+		}
+		res.Entries = append(res.Entries, entry)
+	}
+	return res, nil
 }
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Shared by all prompts, a new reader per prompt would lose buffered input
+var stdin = bufio.NewReader(os.Stdin)
+
+// Asks the user for confirmation on stdin, defaults to no,
+// the question goes to stderr to keep stdout clean for results
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	answer, err := stdin.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// Asks the user for a value on stdin, an empty answer returns def,
+// hint is shown instead of def if not empty (e.g. to hide secrets)
+func prompt(question string, def string, hint string) (string, error) {
+	if hint == "" {
+		hint = def
+	}
+	if hint != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, hint)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+	}
+	answer, err := stdin.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || answer == "") {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// Asks the user for a secret on stdin without echoing it (if stdin is a terminal),
+// an empty answer returns def
+func promptSecret(question string, def string, hint string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return prompt(question, def, hint)
+	}
+	if hint != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, hint)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+	}
+	answer, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	if len(answer) == 0 {
+		return def, nil
+	}
+	return string(answer), nil
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/clip"
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/timeparse"
+	"github.com/spf13/cobra"
+)
+
+var renewCmd = &cobra.Command{
+	Use:   "renew [short link | object key]",
+	Short: "Regenerates expired or expiring links of private uploads",
+	Long: `Creates a fresh presigned MinIO link for a private upload and points the
+existing short link to it, so links you already shared keep working.
+
+With YOURLS updating short links requires the API edit url plugin ("update" action).
+Use --all-expiring-within to renew every private upload from your local history
+whose link expires within the given duration.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flag("all-expiring-within").Value.String() != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		startTime := time.Now()
+
+		cfgPath := cmd.Flag("config").Value.String()
+		logsPath := cmd.Flag("logs").Value.String()
+		debug, err := cmd.Flags().GetBool("debug")
+		cobra.CheckErr(err)
+		var expiry time.Duration
+		if input := cmd.Flag("expiry").Value.String(); input != "" {
+			expiry, err = timeparse.ParseDuration(input)
+			cobra.CheckErr(err)
+		}
+		var within time.Duration
+		if input := cmd.Flag("all-expiring-within").Value.String(); input != "" {
+			within, err = timeparse.ParseDuration(input)
+			cobra.CheckErr(err)
+		}
+
+		if strings.Contains(logsPath, "./") {
+			exe, err := os.Executable()
+			cobra.CheckErr(err)
+
+			logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+		}
+
+		renewLogger := log.NewLogger().
+			WithDirectory(logsPath).
+			WithName("renew").
+			WithDebug(debug).
+			WithConsoleOutput(debug)
+
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			renewLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		renewLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
+
+		if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			renewLogger.Warn("storage not using SSL / TLS (INSECURE)")
+		}
+
+		if endpoint := cfg.ShortenerEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			renewLogger.Warn("shortener not using SSL / TLS (INSECURE)")
+		}
+
+		stopChan := make(chan bool, 1)
+		cancelChannel := make(chan os.Signal, 1)
+		signal.Notify(cancelChannel, os.Interrupt, syscall.SIGTERM)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			select {
+			case sig := <-cancelChannel:
+				renewLogger.Debug(fmt.Sprintf("received sys signal: %s", sig.String()))
+				cancel()
+				return
+			case <-stopChan:
+				renewLogger.Debug("received stop signal")
+				return
+			}
+		}()
+
+		objectStore, err := newObjectStore(logsPath, debug, cfg)
+		if err != nil {
+			renewLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		linkShortener, err := newShortener(logsPath, debug, cfg)
+		if err != nil {
+			renewLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		historyStore, err := history.NewStore("")
+		if err != nil {
+			renewLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		var targets []linkTarget
+		if within > 0 {
+			targets, err = expiringTargets(historyStore, within)
+		} else {
+			var target linkTarget
+			target, err = resolveLinkTarget(ctx, objectStore, linkShortener, historyStore, args[0])
+			targets = append(targets, target)
+		}
+		if err != nil {
+			renewLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		res, failed := renewTargets(
+			ctx,
+			objectStore,
+			linkShortener,
+			historyStore,
+			targets,
+			expiry,
+			renewLogger,
+		)
+		if len(targets) == 1 && failed == 0 {
+			if err := clip.CopyToClipboard(res.Renewed[0].ShortURL); err != nil {
+				renewLogger.Warn(err.Error())
+			}
+		}
+		printResult(res)
+
+		close(stopChan)
+		close(cancelChannel)
+		renewLogger.Debug("closed stop and cancel channels")
+
+		if failed > 0 {
+			renewLogger.Error(fmt.Sprintf("%d of %d renewals failed", failed, len(targets)))
+			os.Exit(1)
+		}
+
+		renewLogger.Info("Renewing done")
+		renewLogger.Debug(fmt.Sprintf("took %s", time.Since(startTime).String()))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renewCmd)
+
+	renewCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
+	renewCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	renewCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	renewCmd.Flags().
+		StringP("expiry", "e", "", "Sets the expiry of the new link (max 7d, default from config)")
+	renewCmd.Flags().
+		String("all-expiring-within", "", "Renews all private uploads expiring within (e.g. 24h)")
+}
+
+// Collects all private uploads from the history expiring within the given duration
+func expiringTargets(historyStore *history.Store, within time.Duration) ([]linkTarget, error) {
+	entries, err := historyStore.List(history.Filter{})
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(within)
+	seen := make(map[string]bool)
+	var targets []linkTarget
+	for _, entry := range entries {
+		if entry.Public || entry.Expires == nil || entry.Expires.After(deadline) {
+			continue
+		}
+		key := entry.Bucket + "/" + entry.Object
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		targets = append(targets, linkTarget{
+			bucket:   entry.Bucket,
+			object:   entry.Object,
+			shortURL: entry.ShortURL,
+		})
+	}
+	return targets, nil
+}
+
+// Renews all targets, failures are logged and reported per target,
+// returns the result and the amount of failed renewals
+func renewTargets(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	linkShortener shortener.Shortener,
+	historyStore *history.Store,
+	targets []linkTarget,
+	expiry time.Duration,
+	logger *log.Logger,
+) (*results.RenewResult, int) {
+	res := &results.RenewResult{Renewed: make([]results.Renewal, 0, len(targets))}
+	failed := 0
+	for _, target := range targets {
+		renewal := results.Renewal{Bucket: target.bucket, Object: target.object}
+		link, shortenedURL, expires, err := renewLink(
+			ctx,
+			objectStore,
+			linkShortener,
+			historyStore,
+			target,
+			expiry,
+		)
+		if err != nil {
+			failed++
+			logger.Error(fmt.Sprintf("failed to renew %s: %s", target.object, err))
+			renewal.Error = err.Error()
+			res.Renewed = append(res.Renewed, renewal)
+			continue
+		}
+		renewal.MinioURL = link
+		renewal.ShortURL = shortenedURL
+		renewal.Expires = &expires
+		res.Renewed = append(res.Renewed, renewal)
+	}
+	return res, failed
+}
+
+// Renews the presigned link, points the short link to it (or creates a new one)
+// and updates the history, returns the new MinIO link, the short link and the expiry
+func renewLink(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	linkShortener shortener.Shortener,
+	historyStore *history.Store,
+	target linkTarget,
+	expiry time.Duration,
+) (string, string, time.Time, error) {
+	link, expires, err := objectStore.RenewLink(ctx, target.bucket, target.object, expiry)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	shortenedURL := target.shortURL
+	if shortenedURL != "" {
+		err = linkShortener.UpdateURL(ctx, shortenedURL, link)
+	}
+	// Shorteners which can not update links (e.g. none) get a new link instead
+	if shortenedURL == "" || errors.Is(err, shortener.ErrUnsupported) {
+		shortenedURL, err = linkShortener.ShortenURL(ctx, link)
+	}
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	_, err = historyStore.Update(target.bucket, target.object, func(e *history.Entry) {
+		e.MinioURL = link
+		e.ShortURL = shortenedURL
+		e.Expires = &expires
+	})
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	return link, shortenedURL, expires, nil
+}
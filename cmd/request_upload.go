@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/devusSs/minio-link/internal/clip"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/timeparse"
+	"github.com/spf13/cobra"
+)
+
+var requestUploadCmd = &cobra.Command{
+	Use:   "request-upload",
+	Short: "Creates a link others can upload files to without credentials",
+	Long: `Creates a presigned link others can upload files to without any credentials (MinIO
+only). Uploaded files are stored in the private bucket below "inbox/<prefix>/", use
+the inbox command to list and download them.
+
+By default the link allows a single PUT request (e.g. "curl -T") for the file named
+by --name, it is shortened and copied to your clipboard. With --post a POST policy
+allows any number of files below the prefix and can limit their size. As the form
+fields of the policy have to be sent along, the whole curl command is copied instead
+(POST requests can not follow the redirect of a short link).`,
+	Example: `  minio-link request-upload --name logs.tar.gz --expiry 3d
+  minio-link request-upload --post --prefix acme --max-size 2GiB --content-type application/gzip`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		startTime := time.Now()
+
+		cfgPath := cmd.Flag("config").Value.String()
+		logsPath := cmd.Flag("logs").Value.String()
+		debug, err := cmd.Flags().GetBool("debug")
+		cobra.CheckErr(err)
+		req := storage.UploadRequest{
+			Prefix:      cmd.Flag("prefix").Value.String(),
+			Name:        cmd.Flag("name").Value.String(),
+			ContentType: cmd.Flag("content-type").Value.String(),
+		}
+		req.Post, err = cmd.Flags().GetBool("post")
+		cobra.CheckErr(err)
+		if input := cmd.Flag("max-size").Value.String(); input != "" {
+			maxSize, err := humanize.ParseBytes(input)
+			cobra.CheckErr(err)
+			req.MaxSize = int64(maxSize)
+		}
+		if input := cmd.Flag("expiry").Value.String(); input != "" {
+			req.Expiry, err = timeparse.ParseDuration(input)
+			cobra.CheckErr(err)
+		}
+		if req.Post && req.Name != "" {
+			cobra.CheckErr("--name can not be used with --post, files keep their own names")
+		}
+		if !req.Post && req.MaxSize > 0 {
+			cobra.CheckErr("--max-size requires --post, PUT links can not limit the size")
+		}
+
+		if strings.Contains(logsPath, "./") {
+			exe, err := os.Executable()
+			cobra.CheckErr(err)
+
+			logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+		}
+
+		requestLogger := log.NewLogger().
+			WithDirectory(logsPath).
+			WithName("request-upload").
+			WithDebug(debug).
+			WithConsoleOutput(debug)
+
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			requestLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		requestLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
+
+		if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			requestLogger.Warn("storage not using SSL / TLS (INSECURE)")
+		}
+
+		if endpoint := cfg.ShortenerEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			requestLogger.Warn("shortener not using SSL / TLS (INSECURE)")
+		}
+
+		stopChan := make(chan bool, 1)
+		cancelChannel := make(chan os.Signal, 1)
+		signal.Notify(cancelChannel, os.Interrupt, syscall.SIGTERM)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			select {
+			case sig := <-cancelChannel:
+				requestLogger.Debug(fmt.Sprintf("received sys signal: %s", sig.String()))
+				cancel()
+				return
+			case <-stopChan:
+				requestLogger.Debug("received stop signal")
+				return
+			}
+		}()
+
+		objectStore, err := newObjectStore(logsPath, debug, cfg)
+		if err != nil {
+			requestLogger.Error(err.Error())
+			os.Exit(1)
+		}
+		requester, ok := objectStore.(storage.Requester)
+		if !ok {
+			requestLogger.Error(fmt.Errorf("upload requests: %w", storage.ErrUnsupported).Error())
+			os.Exit(1)
+		}
+
+		linkShortener, err := newShortener(logsPath, debug, cfg)
+		if err != nil {
+			requestLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		grant, err := requester.RequestUpload(ctx, req)
+		if err != nil {
+			requestLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		res := &results.UploadRequestResult{
+			Method:      grant.Method,
+			Bucket:      grant.Bucket,
+			Prefix:      grant.Prefix,
+			Object:      grant.Object,
+			MinioURL:    grant.URL,
+			Headers:     grant.Headers,
+			FormData:    grant.FormData,
+			MaxSize:     grant.MaxSize,
+			ContentType: grant.ContentType,
+			Expires:     &grant.Expires,
+		}
+		if grant.Method == http.MethodPut {
+			res.ShortURL, err = shortenAndCopy(ctx, linkShortener, grant.URL)
+			if err != nil {
+				requestLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			res.Command = uploadCommand(grant, res.ShortURL)
+		} else {
+			res.Command = uploadCommand(grant, grant.URL)
+			if err := clip.CopyToClipboard(res.Command); err != nil {
+				requestLogger.Error(err.Error())
+				os.Exit(1)
+			}
+		}
+		printResult(res)
+
+		close(stopChan)
+		close(cancelChannel)
+		requestLogger.Debug("closed stop and cancel channels")
+
+		requestLogger.Info("Requesting upload done")
+		requestLogger.Debug(fmt.Sprintf("took %s", time.Since(startTime).String()))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(requestUploadCmd)
+
+	requestUploadCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
+	requestUploadCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	requestUploadCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	requestUploadCmd.Flags().
+		String("prefix", "", "Sets the folder below inbox/ files are stored in (default random)")
+	requestUploadCmd.Flags().
+		String("name", "", "Sets the name of the file uploaded via a PUT link (default random)")
+	requestUploadCmd.Flags().
+		Bool("post", false, "Creates a POST policy for any number of files instead of a PUT link")
+	requestUploadCmd.Flags().
+		String("max-size", "", "Sets the maximum size of a file (e.g. 2GiB, requires --post)")
+	requestUploadCmd.Flags().
+		String("content-type", "", "Sets the content type uploaded files must have")
+	requestUploadCmd.Flags().
+		StringP("expiry", "e", "", "Sets the expiry of the link (max 7d, default from config)")
+}
+
+// Builds the curl command uploading a file with the grant, the file is left as placeholder
+func uploadCommand(grant *storage.UploadGrant, link string) string {
+	args := []string{"curl", "--fail"}
+	if grant.Method == http.MethodPut {
+		// Follows the redirect of the short link
+		args = append(args, "-L", "-T", shellQuote(uploadFilePlaceholder))
+		for _, name := range slices.Sorted(maps.Keys(grant.Headers)) {
+			args = append(args, "-H", shellQuote(name+": "+grant.Headers[name]))
+		}
+	} else {
+		// S3 ignores form fields sent after the file
+		for _, name := range slices.Sorted(maps.Keys(grant.FormData)) {
+			args = append(args, "-F", shellQuote(name+"="+grant.FormData[name]))
+		}
+		args = append(args, "-F", shellQuote("file=@"+uploadFilePlaceholder))
+	}
+	return strings.Join(append(args, shellQuote(link)), " ")
+}
+
+// Quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+const (
+	uploadFilePlaceholder string = "<file>"
+)
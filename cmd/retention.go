@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/timeparse"
+	"github.com/spf13/cobra"
+)
+
+var retentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Shows and changes the retention and legal hold of uploaded files",
+	Long: `Object locks protect uploaded files from being deleted or overwritten (MinIO only).
+The bucket has to be created with LINK_MINIO_OBJECT_LOCKING=true, locking can not
+be enabled for existing buckets.
+
+A governance retention can be shortened or removed by users allowed to bypass it,
+a compliance retention can only be extended and can not be removed by anyone until
+it expires. A legal hold protects a file until it is removed, regardless of its
+retention.`,
+}
+
+var retentionShowCmd = &cobra.Command{
+	Use:   "show <short link | object key>...",
+	Short: "Shows the retention and legal hold of uploaded files",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRetention(cmd, args, nil)
+	},
+}
+
+var retentionSetCmd = &cobra.Command{
+	Use:   "set <short link | object key>...",
+	Short: "Sets the retention and / or legal hold of uploaded files",
+	Example: `  minio-link retention set https://short.link/abc --retain 30d --mode compliance
+  minio-link retention set invoices/2026-10.pdf --legal-hold`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		lock, err := parseLockFlags(cmd)
+		cobra.CheckErr(err)
+		if lock.IsZero() {
+			cobra.CheckErr("nothing to set, pass --retain and / or --legal-hold")
+		}
+		confirmed, err := confirmCompliance(cmd, lock, fmt.Sprintf("%d object(s)", len(args)))
+		cobra.CheckErr(err)
+		if !confirmed {
+			fmt.Fprintln(os.Stderr, "Aborted")
+			return
+		}
+
+		runRetention(cmd, args, func(
+			ctx context.Context,
+			locker storage.Locker,
+			target linkTarget,
+		) error {
+			if lock.Mode != "" {
+				err := locker.SetRetention(
+					ctx,
+					target.bucket,
+					target.object,
+					lock.Mode,
+					lock.RetainUntil,
+				)
+				if err != nil {
+					return err
+				}
+			}
+			if lock.LegalHold {
+				return locker.SetLegalHold(ctx, target.bucket, target.object, true)
+			}
+			return nil
+		})
+	},
+}
+
+var retentionClearCmd = &cobra.Command{
+	Use:   "clear <short link | object key>...",
+	Short: "Removes the retention and / or legal hold of uploaded files",
+	Long: `Removes the retention and the legal hold of uploaded files, use --retention or
+--legal-hold to only remove one of them.
+
+Removing a governance retention requires the permission to bypass it
+(s3:BypassGovernanceRetention), compliance retentions can not be removed.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		clearRetention, err := cmd.Flags().GetBool("retention")
+		cobra.CheckErr(err)
+		clearLegalHold, err := cmd.Flags().GetBool("legal-hold")
+		cobra.CheckErr(err)
+		if !clearRetention && !clearLegalHold {
+			clearRetention, clearLegalHold = true, true
+		}
+
+		runRetention(cmd, args, func(
+			ctx context.Context,
+			locker storage.Locker,
+			target linkTarget,
+		) error {
+			if clearLegalHold {
+				err := locker.SetLegalHold(ctx, target.bucket, target.object, false)
+				if err != nil {
+					return err
+				}
+			}
+			if clearRetention {
+				return locker.ClearRetention(ctx, target.bucket, target.object)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(retentionCmd)
+	retentionCmd.AddCommand(retentionShowCmd)
+	retentionCmd.AddCommand(retentionSetCmd)
+	retentionCmd.AddCommand(retentionClearCmd)
+
+	retentionCmd.PersistentFlags().
+		StringP("config", "c", "", "Sets the path of our env file if wanted")
+	retentionCmd.PersistentFlags().
+		StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	retentionCmd.PersistentFlags().
+		BoolP("debug", "d", false, "Sets the debug mode for our application")
+
+	retentionSetCmd.Flags().
+		String("retain", "", "Locks files for a duration (30d) or until a date (2027-01-01)")
+	retentionSetCmd.Flags().String(
+		"mode",
+		storage.RetentionGovernance,
+		"Sets the retention mode (governance, compliance)",
+	)
+	retentionSetCmd.Flags().Bool("legal-hold", false, "Locks files until the legal hold is removed")
+	retentionSetCmd.Flags().
+		BoolP("yes", "y", false, "Skips the confirmation prompt of compliance retentions")
+
+	retentionClearCmd.Flags().Bool("retention", false, "Only removes the retention")
+	retentionClearCmd.Flags().Bool("legal-hold", false, "Only removes the legal hold")
+}
+
+// Resolves every argument, runs action (if any) on the object and prints its lock
+// afterwards, exits with status 1 if any object failed
+func runRetention(
+	cmd *cobra.Command,
+	args []string,
+	action func(ctx context.Context, locker storage.Locker, target linkTarget) error,
+) {
+	startTime := time.Now()
+
+	cfgPath := cmd.Flag("config").Value.String()
+	logsPath := cmd.Flag("logs").Value.String()
+	debug, err := cmd.Flags().GetBool("debug")
+	cobra.CheckErr(err)
+
+	if strings.Contains(logsPath, "./") {
+		exe, err := os.Executable()
+		cobra.CheckErr(err)
+
+		logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+	}
+
+	retentionLogger := log.NewLogger().
+		WithDirectory(logsPath).
+		WithName("retention").
+		WithDebug(debug).
+		WithConsoleOutput(debug)
+
+	cfg, err := loadConfig(cfgPath)
+	if err != nil {
+		retentionLogger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	retentionLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
+
+	if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+		!strings.Contains(endpoint, "https://") {
+		retentionLogger.Warn("storage not using SSL / TLS (INSECURE)")
+	}
+
+	if endpoint := cfg.ShortenerEndpoint(); endpoint != "" &&
+		!strings.Contains(endpoint, "https://") {
+		retentionLogger.Warn("shortener not using SSL / TLS (INSECURE)")
+	}
+
+	stopChan := make(chan bool, 1)
+	cancelChannel := make(chan os.Signal, 1)
+	signal.Notify(cancelChannel, os.Interrupt, syscall.SIGTERM)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case sig := <-cancelChannel:
+			retentionLogger.Debug(fmt.Sprintf("received sys signal: %s", sig.String()))
+			cancel()
+			return
+		case <-stopChan:
+			retentionLogger.Debug("received stop signal")
+			return
+		}
+	}()
+
+	objectStore, err := newObjectStore(logsPath, debug, cfg)
+	if err != nil {
+		retentionLogger.Error(err.Error())
+		os.Exit(1)
+	}
+	if _, ok := objectStore.(storage.Locker); !ok {
+		retentionLogger.Error(fmt.Errorf("object locks: %w", storage.ErrUnsupported).Error())
+		os.Exit(1)
+	}
+
+	linkShortener, err := newShortener(logsPath, debug, cfg)
+	if err != nil {
+		retentionLogger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	historyStore, err := history.NewStore("")
+	if err != nil {
+		retentionLogger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	res := &results.RetentionResult{Objects: make([]results.ObjectLock, 0, len(args))}
+	failed := 0
+	for _, input := range args {
+		objectLock, err := retentionTarget(
+			ctx,
+			objectStore,
+			linkShortener,
+			historyStore,
+			input,
+			action,
+		)
+		if err != nil {
+			failed++
+			retentionLogger.Error(fmt.Sprintf("retention of %s failed: %s", input, err))
+			objectLock.Error = err.Error()
+		}
+		res.Objects = append(res.Objects, objectLock)
+	}
+	printResult(res)
+
+	close(stopChan)
+	close(cancelChannel)
+	retentionLogger.Debug("closed stop and cancel channels")
+
+	if failed > 0 {
+		retentionLogger.Error(fmt.Sprintf("%d of %d objects failed", failed, len(args)))
+		os.Exit(1)
+	}
+
+	retentionLogger.Info("Retention done")
+	retentionLogger.Debug(fmt.Sprintf("took %s", time.Since(startTime).String()))
+}
+
+// Resolves the input, runs action on it and returns the resulting lock
+func retentionTarget(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	linkShortener shortener.Shortener,
+	historyStore *history.Store,
+	input string,
+	action func(ctx context.Context, locker storage.Locker, target linkTarget) error,
+) (results.ObjectLock, error) {
+	objectLock := results.ObjectLock{Object: input}
+	target, err := resolveLinkTarget(ctx, objectStore, linkShortener, historyStore, input)
+	if err != nil {
+		return objectLock, err
+	}
+	objectLock.Bucket = target.bucket
+	objectLock.Object = target.object
+	// Checked by runRetention
+	locker := objectStore.(storage.Locker)
+	if action != nil {
+		if err := action(ctx, locker, target); err != nil {
+			return objectLock, err
+		}
+	}
+	lock, err := locker.GetLock(ctx, target.bucket, target.object)
+	if err != nil {
+		return objectLock, err
+	}
+	objectLock.Lock = results.NewLock(lock.Mode, lock.RetainUntil, lock.LegalHold)
+	return objectLock, nil
+}
+
+// Reads --retain, --mode and --legal-hold, the lock is zero if none of them is set
+func parseLockFlags(cmd *cobra.Command) (storage.Lock, error) {
+	var lock storage.Lock
+	var err error
+	lock.LegalHold, err = cmd.Flags().GetBool("legal-hold")
+	if err != nil {
+		return storage.Lock{}, err
+	}
+	input := cmd.Flag("retain").Value.String()
+	if input == "" {
+		if cmd.Flags().Changed("mode") {
+			return storage.Lock{}, fmt.Errorf("--mode requires --retain")
+		}
+		return lock, nil
+	}
+	lock.Mode, err = storage.ParseRetentionMode(cmd.Flag("mode").Value.String())
+	if err != nil {
+		return storage.Lock{}, err
+	}
+	lock.RetainUntil, _, err = timeparse.ParseTime(input, time.Now())
+	if err != nil {
+		return storage.Lock{}, err
+	}
+	if !lock.RetainUntil.After(time.Now()) {
+		return storage.Lock{}, fmt.Errorf("retention %s is in the past", input)
+	}
+	return lock, nil
+}
+
+// Asks before objects are locked in compliance mode unless --yes is set, nobody
+// (not even the root user) can shorten or remove a compliance retention
+func confirmCompliance(cmd *cobra.Command, lock storage.Lock, what string) (bool, error) {
+	if lock.Mode != storage.RetentionCompliance {
+		return true, nil
+	}
+	yes, err := cmd.Flags().GetBool("yes")
+	if err != nil {
+		return false, err
+	}
+	if yes {
+		return true, nil
+	}
+	question := fmt.Sprintf(
+		"Lock %s in compliance mode until %s? This can not be undone",
+		what,
+		lock.RetainUntil.Format(time.RFC3339),
+	)
+	return confirm(question), nil
+}
+
+// Explains how a locked object can be deleted, if at all
+func lockHint(lock storage.Lock) string {
+	var hints []string
+	switch {
+	case lock.Retained() && lock.Mode == storage.RetentionCompliance:
+		hints = append(hints, "compliance retentions can not be removed before they expire")
+	case lock.Retained():
+		hints = append(hints, `remove the retention with "minio-link retention clear --retention" `+
+			"(requires permission to bypass governance retention)")
+	}
+	if lock.LegalHold {
+		hints = append(hints,
+			`remove the legal hold with "minio-link retention clear --legal-hold"`)
+	}
+	return strings.Join(hints, ", ")
+}
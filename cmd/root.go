@@ -7,6 +7,7 @@ import (
 	"slices"
 	"time"
 
+	"github.com/devusSs/minio-link/pkg/output"
 	"github.com/devusSs/minio-link/pkg/system"
 	"github.com/spf13/cobra"
 )
@@ -19,7 +20,17 @@ var (
 		Short: "File management via MinIO and shortening via YOURLS",
 		Long: `Minio-Link is a CLI tool to upload files via MinIO and shorten the share urls via YOURLS.
 It also provides a possibility to download the files via the shortened url.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			outputFormat, err = output.ParseFormat(cmd.Flag("output").Value.String())
+			profileName = cmd.Flag("profile").Value.String()
+			return err
+		},
 	}
+
+	outputFormat output.Format = output.FormatTable
+	// Profile chosen via --profile, see loadConfig for the fallbacks
+	profileName string
 )
 
 func Execute() {
@@ -31,6 +42,16 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(checkOS)
+
+	rootCmd.PersistentFlags().
+		String("output", "table", "Sets the output format (json, yaml, table, plain)")
+	rootCmd.PersistentFlags().
+		String("profile", "", "Sets the config profile to use (default $LINK_PROFILE)")
+}
+
+// Renders the command result to stdout in the format chosen via --output
+func printResult(result output.Result) {
+	cobra.CheckErr(output.Render(os.Stdout, outputFormat, result))
 }
 
 func checkOS() {
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/progress"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves upload, list, delete and renew as an HTTP API",
+	Long: `Starts an HTTP server exposing the upload and link management commands as a
+REST API, e.g. for scripts, CI jobs or other machines without the CLI.
+
+Every request needs an API token from LINK_SERVE_TOKENS (comma separated,
+optionally named like "ci=token" so requests can be told apart in the logs)
+sent as "Authorization: Bearer <token>". Responses are the JSON results of
+the matching commands (like --output json).
+
+  POST   /upload        multipart form (every file part) or raw body,
+                        query: private, expires, name (raw body only)
+  GET    /links         query: limit
+  DELETE /links/{key}   object key or bucket/key
+  POST   /renew         JSON body: {"link": "...", "expiry": "24h"}
+                        or {"all_expiring_within": "24h"}
+
+Serve the API behind a reverse proxy with TLS if it is reachable from other hosts.`,
+	Example: `  minio-link serve --listen 127.0.0.1:8090
+  curl -H "Authorization: Bearer $TOKEN" -F file=@report.pdf http://127.0.0.1:8090/upload`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfgPath := cmd.Flag("config").Value.String()
+		logsPath := cmd.Flag("logs").Value.String()
+		debug, err := cmd.Flags().GetBool("debug")
+		cobra.CheckErr(err)
+		listen := cmd.Flag("listen").Value.String()
+		maxUploadSize, err := humanize.ParseBytes(cmd.Flag("max-upload-size").Value.String())
+		cobra.CheckErr(err)
+
+		if strings.Contains(logsPath, "./") {
+			exe, err := os.Executable()
+			cobra.CheckErr(err)
+
+			logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+		}
+
+		serveLogger := log.NewLogger().
+			WithDirectory(logsPath).
+			WithName("serve").
+			WithDebug(debug).
+			WithConsoleOutput(debug)
+
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			serveLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		serveLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
+
+		if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			serveLogger.Warn("storage not using SSL / TLS (INSECURE)")
+		}
+
+		if endpoint := cfg.ShortenerEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			serveLogger.Warn("shortener not using SSL / TLS (INSECURE)")
+		}
+
+		tokens, err := parseTokens(cfg.ServeTokens)
+		if err != nil {
+			serveLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		objectStore, err := newObjectStore(logsPath, debug, cfg)
+		if err != nil {
+			serveLogger.Error(err.Error())
+			os.Exit(1)
+		}
+		// Nobody is watching the server's terminal for progress bars
+		objectStore.SetProgress(progress.ModeNone)
+
+		linkShortener, err := newShortener(logsPath, debug, cfg)
+		if err != nil {
+			serveLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		historyStore, err := history.NewStore("")
+		if err != nil {
+			serveLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		api := &apiServer{
+			logger:        serveLogger,
+			objectStore:   objectStore,
+			linkShortener: linkShortener,
+			historyStore:  historyStore,
+			tokens:        tokens,
+			maxUploadSize: int64(maxUploadSize),
+			uploads:       make(chan struct{}, maxConcurrentUploads),
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		server := &http.Server{
+			Addr:              listen,
+			Handler:           api.handler(),
+			ReadHeaderTimeout: readHeaderTimeout,
+		}
+
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- server.ListenAndServe()
+		}()
+
+		serveLogger.Info(fmt.Sprintf("Serving API on %s with %d token(s)", listen, len(tokens)))
+		fmt.Fprintf(os.Stderr, "Serving API on http://%s\n", listen)
+
+		select {
+		case err := <-errChan:
+			serveLogger.Error(fmt.Sprintf("failed to serve: %s", err))
+			os.Exit(1)
+		case <-ctx.Done():
+			serveLogger.Debug("received stop signal")
+		}
+
+		// Running requests (e.g. large uploads) get some time to finish
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil &&
+			!errors.Is(err, http.ErrServerClosed) {
+			serveLogger.Error(fmt.Sprintf("failed to shut down: %s", err))
+			os.Exit(1)
+		}
+
+		serveLogger.Info("Server stopped")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
+	serveCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	serveCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	serveCmd.Flags().String("listen", "127.0.0.1:8090", "Sets the address to listen on")
+	serveCmd.Flags().String("max-upload-size", "5GiB", "Sets the maximum size of a request body")
+}
+
+// A client allowed to use the API
+type apiToken struct {
+	// Shown in the logs, the token itself never is
+	name  string
+	token string
+}
+
+// Parses comma or newline separated tokens, optionally prefixed with a name ("ci=token"),
+// "=" because ":" is part of the secret references (file:, cmd: and keyring:)
+func parseTokens(input string) ([]apiToken, error) {
+	var tokens []apiToken
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		token := apiToken{name: fmt.Sprintf("#%d", i+1), token: field}
+		// Trailing "=" is base64 padding of an unnamed token, not a name
+		if name, value, ok := strings.Cut(field, "="); ok && value != "" &&
+			!strings.HasPrefix(value, "=") {
+			token = apiToken{name: name, token: value}
+		}
+		if environment.IsSecretRef(token.token) {
+			return nil, fmt.Errorf(
+				"api token %s is a secret reference, reference the whole LINK_SERVE_TOKENS instead",
+				token.name,
+			)
+		}
+		if len(token.token) < minTokenLength {
+			return nil, fmt.Errorf(
+				"api token %s is too short (min %d characters)",
+				token.name,
+				minTokenLength,
+			)
+		}
+		tokens = append(tokens, token)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("LINK_SERVE_TOKENS is required to serve the API")
+	}
+	return tokens, nil
+}
+
+const (
+	minTokenLength    int           = 16
+	readHeaderTimeout time.Duration = 10 * time.Second
+	shutdownTimeout   time.Duration = 30 * time.Second
+)
@@ -0,0 +1,380 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/output"
+	"github.com/devusSs/minio-link/pkg/timeparse"
+)
+
+// Handles the API requests of the serve command, see serveCmd for the endpoints
+type apiServer struct {
+	logger        *log.Logger
+	objectStore   storage.ObjectStore
+	linkShortener shortener.Shortener
+	historyStore  *history.Store
+	tokens        []apiToken
+	maxUploadSize int64
+	// Holds a slot per running upload, every upload buffers a part in memory
+	uploads chan struct{}
+}
+
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /upload", s.handleUpload)
+	mux.HandleFunc("GET /links", s.handleList)
+	mux.HandleFunc("DELETE /links/{key...}", s.handleDelete)
+	mux.HandleFunc("POST /renew", s.handleRenew)
+	return s.authenticate(mux)
+}
+
+// Rejects requests without a valid token and logs every request with the token's name
+func (s *apiServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		client, ok := s.client(r)
+		if !ok {
+			s.logger.Warn(fmt.Sprintf("rejected %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="minio-link"`)
+			writeAPIError(w, http.StatusUnauthorized, "missing or invalid api token")
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		s.logger.Info(fmt.Sprintf(
+			"%s %s %d (%s, took %s)",
+			r.Method,
+			r.URL.Path,
+			rec.status,
+			client,
+			time.Since(startTime).String(),
+		))
+	})
+}
+
+// Returns the name of the token sent via "Authorization: Bearer <token>"
+func (s *apiServer) client(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	// Compares against every token so the timing does not reveal which one matched
+	name := ""
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.token)) == 1 {
+			name = t.name
+		}
+	}
+	return name, name != ""
+}
+
+// POST /upload, uploads every file of a multipart form or the raw body
+func (s *apiServer) handleUpload(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	public := true
+	if input := query.Get("private"); input != "" {
+		private, err := strconv.ParseBool(input)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid private: %s", input))
+			return
+		}
+		public = !private
+	}
+	var opts storage.UploadOptions
+	if input := query.Get("expires"); input != "" {
+		var err error
+		opts.Expires, _, err = timeparse.ParseTime(input, time.Now())
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !opts.Expires.After(time.Now()) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("expiry %s is in the past", input))
+			return
+		}
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxUploadSize)
+
+	res := &results.UploadResult{Files: []results.Upload{}}
+	var errs []error
+	add := func(upload results.Upload, err error) {
+		res.Files = append(res.Files, upload)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for {
+			part, err := reader.NextPart()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				add(results.Upload{Error: err.Error()}, err)
+				break
+			}
+			// Plain form fields are ignored, options are passed as query parameters
+			if part.FileName() == "" {
+				continue
+			}
+			add(s.upload(r.Context(), part, part.FileName(), public, opts))
+		}
+		if len(res.Files) == 0 {
+			writeAPIError(w, http.StatusBadRequest, "no files in multipart form")
+			return
+		}
+	} else {
+		// Lets the backend upload in a single request if the body is small enough
+		if r.ContentLength > 0 {
+			opts.Size = r.ContentLength
+		}
+		add(s.upload(r.Context(), r.Body, query.Get("name"), public, opts))
+	}
+
+	if len(res.Files) == 1 {
+		res.ShortURL = res.Files[0].ShortURL
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		writeAPIResult(w, apiErrorStatus(err), res)
+		return
+	}
+	writeAPIResult(w, http.StatusCreated, res)
+}
+
+// Uploads a single file, shortens its link and records it in the history
+func (s *apiServer) upload(
+	ctx context.Context,
+	r io.Reader,
+	name string,
+	public bool,
+	opts storage.UploadOptions,
+) (results.Upload, error) {
+	if name != "" {
+		// Only the extension is used, never trust client paths
+		name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	}
+	select {
+	case s.uploads <- struct{}{}:
+		defer func() { <-s.uploads }()
+	case <-ctx.Done():
+		err := fmt.Errorf("failed to wait for upload slot: %w", ctx.Err())
+		return results.Upload{File: name, Public: public, Error: err.Error()}, err
+	}
+	upload, err := s.objectStore.UploadStream(ctx, r, name, public, opts)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to upload %s: %s", name, err))
+		return results.Upload{File: name, Public: public, Error: err.Error()}, err
+	}
+
+	var shortenedURL string
+	var shortenErr error
+	// Links of SSE-C objects only work with the key, there is nothing to share
+	if !upload.KeyRequired {
+		shortenedURL, shortenErr = s.linkShortener.ShortenURL(ctx, upload.URL)
+	}
+	if shortenErr != nil {
+		// The object is uploaded anyway, keep its link in the history and the response
+		s.logger.Error(fmt.Sprintf("failed to shorten %s: %s", upload.URL, shortenErr))
+		shortenedURL = ""
+	}
+	if err := recordUpload(s.historyStore, upload, shortenedURL); err != nil {
+		s.logger.Warn(fmt.Sprintf("failed to record upload in history: %s", err))
+	}
+
+	res := newUploadResult(upload, shortenedURL)
+	if shortenErr != nil {
+		res.Error = shortenErr.Error()
+	}
+	return res, shortenErr
+}
+
+// GET /links, lists the newest short links like the list command
+func (s *apiServer) handleList(w http.ResponseWriter, r *http.Request) {
+	limit := defaultAPILimit
+	if input := r.URL.Query().Get("limit"); input != "" {
+		var err error
+		if limit, err = strconv.Atoi(input); err != nil || limit < 1 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %s", input))
+			return
+		}
+	}
+
+	res, err := listLinks(r.Context(), s.objectStore, s.linkShortener, limit, s.logger)
+	if err != nil {
+		s.logger.Error(err.Error())
+		writeAPIError(w, apiErrorStatus(err), err.Error())
+		return
+	}
+	writeAPIResult(w, http.StatusOK, res)
+}
+
+// DELETE /links/{key}, deletes the object and its short link like the delete command
+func (s *apiServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	target, err := resolveLinkTarget(
+		r.Context(),
+		s.objectStore,
+		s.linkShortener,
+		s.historyStore,
+		r.PathValue("key"),
+	)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, failed := deleteTargets(
+		r.Context(),
+		s.objectStore,
+		s.linkShortener,
+		s.historyStore,
+		[]linkTarget{target},
+		s.logger,
+	)
+	if failed > 0 {
+		writeAPIResult(w, http.StatusInternalServerError, res)
+		return
+	}
+	writeAPIResult(w, http.StatusOK, res)
+}
+
+// Body of POST /renew, mirrors the flags of the renew command
+type renewRequest struct {
+	// Short link, MinIO link or object key
+	Link              string `json:"link"`
+	Expiry            string `json:"expiry"`
+	AllExpiringWithin string `json:"all_expiring_within"`
+}
+
+// POST /renew, renews a single link or all expiring ones like the renew command
+func (s *apiServer) handleRenew(w http.ResponseWriter, r *http.Request) {
+	var req renewRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err))
+		return
+	}
+	if (req.Link == "") == (req.AllExpiringWithin == "") {
+		writeAPIError(w, http.StatusBadRequest, "either link or all_expiring_within is required")
+		return
+	}
+
+	var expiry time.Duration
+	var err error
+	if req.Expiry != "" {
+		if expiry, err = timeparse.ParseDuration(req.Expiry); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	var targets []linkTarget
+	if req.AllExpiringWithin != "" {
+		var within time.Duration
+		if within, err = timeparse.ParseDuration(req.AllExpiringWithin); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		targets, err = expiringTargets(s.historyStore, within)
+	} else {
+		var target linkTarget
+		target, err = resolveLinkTarget(
+			r.Context(),
+			s.objectStore,
+			s.linkShortener,
+			s.historyStore,
+			req.Link,
+		)
+		targets = append(targets, target)
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, failed := renewTargets(
+		r.Context(),
+		s.objectStore,
+		s.linkShortener,
+		s.historyStore,
+		targets,
+		expiry,
+		s.logger,
+	)
+	if failed > 0 {
+		writeAPIResult(w, http.StatusInternalServerError, res)
+		return
+	}
+	writeAPIResult(w, http.StatusOK, res)
+}
+
+// Body of error responses which have no result
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// Writes the result as JSON, identical to --output json of the matching command
+func writeAPIResult(w http.ResponseWriter, status int, result output.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = output.Render(w, output.FormatJSON, result)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(apiError{Error: msg})
+}
+
+// Maps errors of the backends to status codes
+func apiErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, storage.ErrUnsupported), errors.Is(err, shortener.ErrUnsupported):
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
+
+// Remembers the status code for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+const (
+	defaultAPILimit      int   = 20
+	maxRequestSize       int64 = 1 << 20
+	maxConcurrentUploads int   = 4
+)
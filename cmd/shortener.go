@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/kutt"
+	"github.com/devusSs/minio-link/internal/shlink"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/internal/yourls"
+)
+
+// Creates the url shortener chosen via LINK_SHORTENER
+func newShortener(
+	dir string,
+	debug bool,
+	cfg *environment.EnvConfig,
+) (shortener.Shortener, error) {
+	switch strings.ToLower(cfg.Shortener) {
+	case shortener.BackendYOURLS:
+		switch strings.ToLower(cfg.YourlsAuth) {
+		case yourls.AuthTimestamp, yourls.AuthSignature:
+			if cfg.YourlsSignatureKey == "" {
+				return nil, fmt.Errorf(
+					"LINK_YOURLS_SIGNATURE_KEY is required for the yourls shortener",
+				)
+			}
+		case yourls.AuthPassword:
+			if cfg.YourlsUsername == "" || cfg.YourlsPassword == "" {
+				return nil, fmt.Errorf(
+					"LINK_YOURLS_USERNAME and LINK_YOURLS_PASSWORD are required for password auth",
+				)
+			}
+		default:
+			return nil, fmt.Errorf(
+				"unsupported yourls auth: %s (supported: timestamp, signature, password)",
+				cfg.YourlsAuth,
+			)
+		}
+		return yourls.NewClient(dir, debug, cfg), nil
+	case shortener.BackendShlink:
+		if cfg.ShlinkAPIKey == "" {
+			return nil, fmt.Errorf("LINK_SHLINK_API_KEY is required for the shlink shortener")
+		}
+		return shlink.NewClient(dir, debug, cfg), nil
+	case shortener.BackendKutt:
+		if cfg.KuttAPIKey == "" {
+			return nil, fmt.Errorf("LINK_KUTT_API_KEY is required for the kutt shortener")
+		}
+		return kutt.NewClient(dir, debug, cfg), nil
+	case shortener.BackendNone:
+		return shortener.NewNoneClient(), nil
+	}
+	return nil, fmt.Errorf(
+		"unsupported shortener: %s (supported: yourls, shlink, kutt, none)",
+		cfg.Shortener,
+	)
+}
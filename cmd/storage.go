@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/filesystem"
+	"github.com/devusSs/minio-link/internal/minio"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/internal/webdav"
+	"github.com/spf13/cobra"
+)
+
+// Creates the object store chosen via LINK_STORAGE
+func newObjectStore(
+	dir string,
+	debug bool,
+	cfg *environment.EnvConfig,
+) (storage.ObjectStore, error) {
+	switch strings.ToLower(cfg.Storage) {
+	case storage.BackendMinio:
+		if cfg.MinioAccessKey == "" || cfg.MinioAccessSecret == "" {
+			return nil, fmt.Errorf(
+				"LINK_MINIO_ACCESS_KEY and LINK_MINIO_ACCESS_SECRET are required for minio",
+			)
+		}
+		return minio.NewClient(dir, debug, cfg)
+	case storage.BackendFilesystem:
+		return filesystem.NewClient(dir, debug, cfg)
+	case storage.BackendWebDAV:
+		return webdav.NewClient(dir, debug, cfg)
+	}
+	return nil, fmt.Errorf(
+		"unsupported storage: %s (supported: minio, filesystem, webdav)",
+		cfg.Storage,
+	)
+}
+
+// Overrides LINK_MINIO_SSE with the --sse flag of the command if it is set
+func applySSEFlag(cmd *cobra.Command, cfg *environment.EnvConfig) error {
+	value := cmd.Flag("sse").Value.String()
+	if value == "" {
+		return nil
+	}
+	if strings.ToLower(cfg.Storage) != storage.BackendMinio {
+		return fmt.Errorf("--sse is only supported by the minio storage")
+	}
+	sse, err := environment.ParseSSE(value)
+	if err != nil {
+		return err
+	}
+	// Same check as for LINK_MINIO_SSE, the key must never be sent in plain text
+	if sse.Type == environment.SSEC && !cfg.MinioUseSSL {
+		return fmt.Errorf("SSE-C keys are only sent via https, enable LINK_MINIO_USE_SSL")
+	}
+	cfg.MinioSSE = value
+	return nil
+}
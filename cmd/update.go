@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/devusSs/minio-link/internal/results"
 	"github.com/devusSs/minio-link/internal/updater"
 	"github.com/spf13/cobra"
 )
@@ -10,8 +11,14 @@ var updateCmd = &cobra.Command{
 	Short: "Updates the application if there are updates available",
 	Long:  "Updating will only work if you have proper build information setup.",
 	Run: func(cmd *cobra.Command, args []string) {
-		err := updater.CheckForUpdatesAndApply(BuildVersion)
+		update, err := updater.CheckForUpdatesAndApply(BuildVersion)
 		cobra.CheckErr(err)
+		printResult(&results.UpdateResult{
+			CurrentVersion: update.CurrentVersion,
+			LatestVersion:  update.LatestVersion,
+			Updated:        update.Applied,
+			Changelog:      update.Changelog,
+		})
 	},
 }
 
@@ -2,36 +2,117 @@ package cmd
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/devusSs/minio-link/internal/clip"
-	"github.com/devusSs/minio-link/internal/config/environment"
-	"github.com/devusSs/minio-link/internal/minio"
-	"github.com/devusSs/minio-link/internal/yourls"
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/internal/manifest"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/crypt"
 	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/progress"
+	"github.com/devusSs/minio-link/pkg/timeparse"
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
 var uploadCmd = &cobra.Command{
-	Use:   "upload [file path]",
-	Short: "Uploads a file to MinIO and then shortens the url via YOURLS",
-	Args:  cobra.ExactArgs(1),
+	Use:   "upload [file path | directory | glob | -]...",
+	Short: "Uploads a file to MinIO and then shortens the url",
+	Long: `Uploads a file to MinIO and then shortens the url via the configured shortener.
+Uploads are split into parts and checkpointed locally, re-running the
+command on the same file will continue an interrupted upload.
+
+Use "-" as file path to upload from stdin, e.g. "pg_dump db | minio-link upload - --name db.sql".
+
+Multiple files, directories (recursive) and globs are uploaded concurrently
+under a common prefix, the shortened link then points to a generated index
+page listing every file with its own link.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		resume, _ := cmd.Flags().GetBool("resume")
+		abort, _ := cmd.Flags().GetBool("abort")
+		if resume || abort {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		startTime := time.Now()
 
-		file := args[0]
+		var file string
+		if len(args) > 0 {
+			file = args[0]
+		}
 		cfgPath := cmd.Flag("config").Value.String()
 		logsPath := cmd.Flag("logs").Value.String()
 		debug, err := cmd.Flags().GetBool("debug")
 		cobra.CheckErr(err)
+		progressMode, err := progress.ParseMode(cmd.Flag("progress").Value.String())
+		cobra.CheckErr(err)
 		private, err := cmd.Flags().GetBool("private")
 		cobra.CheckErr(err)
+		resume, err := cmd.Flags().GetBool("resume")
+		cobra.CheckErr(err)
+		abort, err := cmd.Flags().GetBool("abort")
+		cobra.CheckErr(err)
+		name := cmd.Flag("name").Value.String()
+		workers, err := cmd.Flags().GetInt("workers")
+		cobra.CheckErr(err)
+		forceNew, err := cmd.Flags().GetBool("force-new")
+		cobra.CheckErr(err)
+		encrypt, err := cmd.Flags().GetBool("encrypt")
+		cobra.CheckErr(err)
+		separateKey, err := cmd.Flags().GetBool("separate-key")
+		cobra.CheckErr(err)
+		if separateKey && !encrypt {
+			cobra.CheckErr("--separate-key requires --encrypt")
+		}
+		if encrypt && !isSingleUpload(args) {
+			cobra.CheckErr("--encrypt only supports a single file (or stdin)")
+		}
+		opts := storage.UploadOptions{ForceNew: forceNew}
+		if input := cmd.Flag("expires").Value.String(); input != "" {
+			opts.Expires, _, err = timeparse.ParseTime(input, time.Now())
+			cobra.CheckErr(err)
+			if !opts.Expires.After(time.Now()) {
+				cobra.CheckErr(fmt.Sprintf("expiry %s is in the past", input))
+			}
+		}
+		opts.Lock, err = parseLockFlags(cmd)
+		cobra.CheckErr(err)
+		if !opts.Expires.IsZero() &&
+			(opts.Lock.LegalHold || opts.Expires.Before(opts.Lock.RetainUntil)) {
+			cobra.CheckErr("--expires can not delete files before their lock ends")
+		}
+		if opts.Lock.Mode == storage.RetentionCompliance {
+			yes, err := cmd.Flags().GetBool("yes")
+			cobra.CheckErr(err)
+			// The answer would be read from the uploaded data
+			if slices.Contains(args, "-") && !yes {
+				cobra.CheckErr("compliance retentions of stdin uploads require --yes")
+			}
+			confirmed, err := confirmCompliance(cmd, opts.Lock, "the uploaded file(s)")
+			cobra.CheckErr(err)
+			if !confirmed {
+				fmt.Fprintln(os.Stderr, "Aborted")
+				return
+			}
+		}
 
 		if strings.Contains(logsPath, "./") {
 			exe, err := os.Executable()
@@ -46,20 +127,27 @@ var uploadCmd = &cobra.Command{
 			WithDebug(debug).
 			WithConsoleOutput(debug)
 
-		cfg, err := environment.Load(cfgPath)
+		cfg, err := loadConfig(cfgPath)
 		if err != nil {
 			uploadLogger.Error(err.Error())
 			os.Exit(1)
 		}
 
+		if err := applySSEFlag(cmd, cfg); err != nil {
+			uploadLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
 		uploadLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
 
-		if !cfg.MinioUseSSL {
-			uploadLogger.Warn("minio not using SSL / TLS (INSECURE)")
+		if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			uploadLogger.Warn("storage not using SSL / TLS (INSECURE)")
 		}
 
-		if !strings.Contains(cfg.YourlsEndpoint, "https://") {
-			uploadLogger.Warn("yourls not using SSL / TLS (INSECURE)")
+		if endpoint := cfg.ShortenerEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			uploadLogger.Warn("shortener not using SSL / TLS (INSECURE)")
 		}
 
 		stopChan := make(chan bool, 1)
@@ -81,49 +169,214 @@ var uploadCmd = &cobra.Command{
 			}
 		}()
 
-		minioClient, err := minio.NewClient(logsPath, debug, cfg)
+		objectStore, err := newObjectStore(logsPath, debug, cfg)
 		if err != nil {
 			uploadLogger.Error(err.Error())
 			os.Exit(1)
 		}
+		objectStore.SetProgress(progressMode)
 
-		minioURL, err := minioClient.UploadFile(ctx, file, !private)
+		linkShortener, err := newShortener(logsPath, debug, cfg)
 		if err != nil {
 			uploadLogger.Error(err.Error())
 			os.Exit(1)
 		}
 
-		if err := clip.CopyToClipboard(minioURL); err != nil {
+		historyStore, err := history.NewStore("")
+		if err != nil {
 			uploadLogger.Error(err.Error())
 			os.Exit(1)
 		}
+		addHistory := func(upload *storage.Upload, shortenedURL string) {
+			if err := recordUpload(historyStore, upload, shortenedURL); err != nil {
+				uploadLogger.Warn(fmt.Sprintf("failed to record upload in history: %s", err))
+			}
+		}
 
-		uploadLogger.Debug("upload to MinIO successful")
+		failed := 0
+		switch {
+		case abort:
+			aborted, err := objectStore.AbortIncompleteUploads(ctx, file)
+			if err != nil {
+				uploadLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			printResult(&results.IncompleteUploadsResult{
+				Uploads: []results.IncompleteUpload{},
+				Aborted: aborted,
+			})
+		case resume:
+			uploads, err := objectStore.ListIncompleteUploads(ctx)
+			if err != nil {
+				uploadLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			res := &results.IncompleteUploadsResult{
+				Uploads: make([]results.IncompleteUpload, 0, len(uploads)),
+			}
+			for _, upload := range uploads {
+				entry := results.IncompleteUpload{
+					Bucket:    upload.Bucket,
+					Object:    upload.Object,
+					UploadID:  upload.UploadID,
+					Initiated: upload.Initiated,
+					File:      upload.FilePath,
+				}
+				if upload.FilePath == "" || (file != "" && !sameFile(file, upload.FilePath)) {
+					res.Uploads = append(res.Uploads, entry)
+					continue
+				}
+				uploaded, shortenedURL, err := uploadAndShorten(
+					ctx,
+					objectStore,
+					linkShortener,
+					historyStore,
+					upload.FilePath,
+					"",
+					upload.Public,
+					opts,
+				)
+				if err != nil {
+					failed++
+					uploadLogger.Error(err.Error())
+					entry.Error = err.Error()
+				} else {
+					addHistory(uploaded, shortenedURL)
+					entry.ShortURL = shortenedURL
+				}
+				res.Uploads = append(res.Uploads, entry)
+			}
+			printResult(res)
+		case encrypt:
+			uploaded, shortenedURL, key, err := uploadEncrypted(
+				ctx,
+				objectStore,
+				linkShortener,
+				file,
+				name,
+				!private,
+				opts,
+				!separateKey,
+			)
+			if err != nil {
+				uploadLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			// The history never contains keys
+			addHistory(uploaded, shortenedURL)
+			res := newUploadResult(uploaded, shortenedURL)
+			res.Key = key.String()
+			if !separateKey {
+				res.MinioURL = linkWithKey(res.MinioURL, key)
+				res.ShortURL = linkWithKey(res.ShortURL, key)
+			}
+			printResult(&results.UploadResult{
+				Files:    []results.Upload{res},
+				ShortURL: res.ShortURL,
+			})
+		case isSingleUpload(args):
+			uploaded, shortenedURL, err := uploadAndShorten(
+				ctx,
+				objectStore,
+				linkShortener,
+				historyStore,
+				file,
+				name,
+				!private,
+				opts,
+			)
+			if err != nil {
+				uploadLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			addHistory(uploaded, shortenedURL)
+			printResult(&results.UploadResult{
+				Files:    []results.Upload{newUploadResult(uploaded, shortenedURL)},
+				ShortURL: shortenedURL,
+			})
+		default:
+			files, err := expandUploadArgs(args)
+			if err != nil {
+				uploadLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			uploadLogger.Debug(fmt.Sprintf("uploading %d files with %d workers", len(files), workers))
 
-		yourlsClient := yourls.NewClient(logsPath, debug, cfg)
-		shortenedURL, err := yourlsClient.ShortenURL(ctx, minioURL)
-		if err != nil {
-			uploadLogger.Error(err.Error())
-			os.Exit(1)
-		}
+			// Concurrent progress bars would garble the terminal, only keep JSON lines
+			if progressMode != progress.ModeJSON {
+				objectStore.SetProgress(progress.ModeNone)
+			}
 
-		uploadLogger.Debug("shortening via YOURLS successful")
+			prefix := batchPrefix(files, !private)
+			batch := uploadBatch(ctx, objectStore, files, prefix, workers, !private, opts)
 
-		if err := clip.CopyToClipboard(shortenedURL); err != nil {
-			uploadLogger.Error(err.Error())
-			os.Exit(1)
+			res := &results.UploadResult{Files: make([]results.Upload, 0, len(batch))}
+			entries := make([]manifest.Entry, 0, len(batch))
+			for _, b := range batch {
+				if b.err != nil {
+					failed++
+					uploadLogger.Error(fmt.Sprintf("failed to upload %s: %s", b.file.path, b.err))
+					res.Files = append(res.Files, results.Upload{
+						File:  b.file.path,
+						Size:  b.file.size,
+						Error: b.err.Error(),
+					})
+					continue
+				}
+				entries = append(
+					entries,
+					manifest.Entry{Name: b.file.name, Size: b.file.size, URL: b.upload.URL},
+				)
+				addHistory(b.upload, "")
+				upload := newUploadResult(b.upload, "")
+				upload.File = b.file.path
+				res.Files = append(res.Files, upload)
+			}
+
+			if len(entries) > 0 {
+				uploaded, shortenedURL, err := uploadManifest(
+					ctx,
+					objectStore,
+					linkShortener,
+					prefix,
+					entries,
+					!private,
+					opts,
+				)
+				if err != nil {
+					uploadLogger.Error(err.Error())
+					os.Exit(1)
+				}
+				addHistory(uploaded, shortenedURL)
+				index := newUploadResult(uploaded, shortenedURL)
+				res.Index = &index
+				res.ShortURL = shortenedURL
+			}
+
+			printResult(res)
 		}
 
 		close(stopChan)
 		close(cancelChannel)
 		uploadLogger.Debug("closed stop and cancel channels")
 
+		if failed > 0 {
+			uploadLogger.Error(fmt.Sprintf("%d upload(s) failed", failed))
+			os.Exit(1)
+		}
+
 		uploadLogger.Info("Uploading and shortening done")
 		uploadLogger.Debug(fmt.Sprintf("took %s", time.Since(startTime).String()))
 
-		uploadLogger.Info(
-			fmt.Sprintf("Links will be valid for %s", cfg.MinioDefaultExpiry.String()),
-		)
+		if opts.Expires.IsZero() {
+			uploadLogger.Info(
+				fmt.Sprintf("Links will be valid for %s", cfg.MinioDefaultExpiry.String()),
+			)
+		} else {
+			uploadLogger.Info(
+				fmt.Sprintf("Files will be deleted after %s", opts.Expires.Format(time.RFC1123)),
+			)
+		}
 	},
 }
 
@@ -133,6 +386,470 @@ func init() {
 	uploadCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
 	uploadCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
 	uploadCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	uploadCmd.Flags().
+		String("progress", "auto", "Sets the progress output (auto, bar, json, none)")
 	uploadCmd.Flags().
 		BoolP("private", "p", false, "Sets the bucket and therefor uploaded files to private")
+	uploadCmd.Flags().
+		Bool("resume", false, "Lists unfinished uploads and resumes those with a local journal")
+	uploadCmd.Flags().
+		Bool("abort", false, "Aborts unfinished uploads (only the given file's if specified)")
+	uploadCmd.Flags().
+		StringP("name", "n", "", "Sets the file name (extension) when uploading from stdin")
+	uploadCmd.Flags().
+		IntP("workers", "w", 4, "Sets the amount of concurrent uploads for multiple files")
+	uploadCmd.Flags().
+		StringP("expires", "e", "", "Deletes files after a duration (3d) or at a date (2026-12-01)")
+	uploadCmd.Flags().
+		Bool("force-new", false, "Uploads even if the same content has been uploaded before")
+	uploadCmd.Flags().
+		Bool("encrypt", false, "Encrypts the file locally, the key is added to the link (#key)")
+	uploadCmd.Flags().
+		Bool("separate-key", false, "Prints the key of an encrypted upload instead of adding it")
+	uploadCmd.Flags().
+		String("sse", "", "Sets the server side encryption (none, s3, kms[:<key-id>], c:<keyfile>)")
+	uploadCmd.Flags().
+		String("retain", "", "Locks files for a duration (30d) or until a date (2027-01-01)")
+	uploadCmd.Flags().String(
+		"mode",
+		storage.RetentionGovernance,
+		"Sets the retention mode (governance, compliance)",
+	)
+	uploadCmd.Flags().Bool("legal-hold", false, "Locks files until the legal hold is removed")
+	uploadCmd.Flags().
+		BoolP("yes", "y", false, "Skips the confirmation prompt of compliance retentions")
+	uploadCmd.MarkFlagsMutuallyExclusive("resume", "abort")
+	uploadCmd.MarkFlagsMutuallyExclusive("encrypt", "resume")
+	uploadCmd.MarkFlagsMutuallyExclusive("encrypt", "abort")
+}
+
+// Uploads the file (or stdin if file is "-") to MinIO, shortens the link
+// and copies it to the clipboard, existing uploads keep their short link
+func uploadAndShorten(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	linkShortener shortener.Shortener,
+	historyStore *history.Store,
+	file string,
+	name string,
+	public bool,
+	opts storage.UploadOptions,
+) (*storage.Upload, string, error) {
+	var upload *storage.Upload
+	var err error
+	if file == "-" {
+		upload, err = objectStore.UploadStream(ctx, os.Stdin, name, public, opts)
+	} else {
+		upload, err = objectStore.UploadFile(ctx, file, public, opts)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if upload.KeyRequired {
+		return upload, "", nil
+	}
+
+	if upload.Existing {
+		shortenedURL, err := reuseShortURL(ctx, linkShortener, historyStore, upload)
+		if err != nil {
+			return nil, "", err
+		}
+		if shortenedURL != "" {
+			if err := clip.CopyToClipboard(shortenedURL); err != nil {
+				return nil, "", err
+			}
+			return upload, shortenedURL, nil
+		}
+	}
+
+	shortenedURL, err := shortenAndCopy(ctx, linkShortener, upload.URL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return upload, shortenedURL, nil
+}
+
+// Encrypts the file (or stdin if file is "-") with a new random key while uploading it
+// and shortens the link, the shortener never sees the key, it is only added to the
+// link copied to the clipboard if keyInLink is set
+func uploadEncrypted(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	linkShortener shortener.Shortener,
+	file string,
+	name string,
+	public bool,
+	opts storage.UploadOptions,
+	keyInLink bool,
+) (*storage.Upload, string, crypt.Key, error) {
+	key, err := crypt.NewKey()
+	if err != nil {
+		return nil, "", crypt.Key{}, err
+	}
+	var src io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, "", crypt.Key{}, fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+		src = f
+		if name == "" {
+			name = filepath.Base(file)
+		}
+	}
+
+	pr, pw := io.Pipe()
+	hash := sha256.New()
+	counter := &storage.CountingWriter{}
+	go func() {
+		enc, err := crypt.NewEncrypter(io.MultiWriter(pw, hash, counter), key)
+		if err == nil {
+			_, err = io.Copy(enc, src)
+		}
+		if err == nil {
+			err = enc.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	opts.Encryption = crypt.Scheme
+	// The object name must not tell anything about the content, so it only gets the
+	// extension of encrypted files, the original name stays in the result and history
+	upload, err := objectStore.UploadStream(ctx, pr, crypt.Extension, public, opts)
+	// Stops the encryption if the upload failed early
+	pr.Close()
+	if err != nil {
+		return nil, "", crypt.Key{}, err
+	}
+	upload.FileName = name
+	if name == "" {
+		upload.FileName = upload.Object
+	}
+	// Size and checksum both describe the stored ciphertext, downloads are verified
+	// against them before they are decrypted
+	upload.Size = counter.N
+	upload.Checksum = hex.EncodeToString(hash.Sum(nil))
+	if upload.KeyRequired {
+		return upload, "", key, nil
+	}
+
+	share := func(link string) string {
+		if keyInLink {
+			return linkWithKey(link, key)
+		}
+		return link
+	}
+	if err := clip.CopyToClipboard(share(upload.URL)); err != nil {
+		return nil, "", crypt.Key{}, err
+	}
+	shortenedURL, err := linkShortener.ShortenURL(ctx, upload.URL)
+	if err != nil {
+		return nil, "", crypt.Key{}, err
+	}
+	if err := clip.CopyToClipboard(share(shortenedURL)); err != nil {
+		return nil, "", crypt.Key{}, err
+	}
+
+	return upload, shortenedURL, key, nil
+}
+
+// Returns the short link of an earlier upload of the same object from the history,
+// links of private objects are pointed to the fresh presigned link,
+// empty if there is none or it can not be updated
+func reuseShortURL(
+	ctx context.Context,
+	linkShortener shortener.Shortener,
+	historyStore *history.Store,
+	upload *storage.Upload,
+) (string, error) {
+	entries, err := historyStore.List(history.Filter{})
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if entry.Bucket != upload.Bucket || entry.Object != upload.Object || entry.ShortURL == "" {
+			continue
+		}
+		if upload.Public {
+			return entry.ShortURL, nil
+		}
+		err := linkShortener.UpdateURL(ctx, entry.ShortURL, upload.URL)
+		if errors.Is(err, shortener.ErrUnsupported) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return entry.ShortURL, nil
+	}
+	return "", nil
+}
+
+// Records the upload in the history, entries of existing uploads are updated instead
+func recordUpload(historyStore *history.Store, upload *storage.Upload, shortenedURL string) error {
+	if upload.Existing {
+		updated, err := historyStore.Update(upload.Bucket, upload.Object, func(e *history.Entry) {
+			e.MinioURL = upload.URL
+			e.ShortURL = shortenedURL
+			e.Expires = results.TimeOrNil(upload.Expires)
+		})
+		if err != nil || updated > 0 {
+			return err
+		}
+	}
+	return historyStore.Add(newHistoryEntry(upload, shortenedURL))
+}
+
+// Copies the MinIO link to the clipboard (in case the shortener fails),
+// shortens it and replaces the clipboard with the shortened link
+func shortenAndCopy(
+	ctx context.Context,
+	linkShortener shortener.Shortener,
+	minioURL string,
+) (string, error) {
+	if err := clip.CopyToClipboard(minioURL); err != nil {
+		return "", err
+	}
+
+	shortenedURL, err := linkShortener.ShortenURL(ctx, minioURL)
+	if err != nil {
+		return "", err
+	}
+
+	if err := clip.CopyToClipboard(shortenedURL); err != nil {
+		return "", err
+	}
+
+	return shortenedURL, nil
+}
+
+func newHistoryEntry(upload *storage.Upload, shortenedURL string) history.Entry {
+	entry := history.Entry{
+		FileName:    upload.FileName,
+		Object:      upload.Object,
+		Bucket:      upload.Bucket,
+		Public:      upload.Public,
+		Size:        upload.Size,
+		ContentType: upload.ContentType,
+		Checksum:    upload.Checksum,
+		Encryption:  upload.Encryption,
+		MinioURL:    upload.URL,
+		ShortURL:    shortenedURL,
+	}
+	if !upload.Expires.IsZero() {
+		entry.Expires = &upload.Expires
+	}
+	if !upload.DeleteAt.IsZero() {
+		entry.DeleteAt = &upload.DeleteAt
+	}
+	return entry
+}
+
+func newUploadResult(upload *storage.Upload, shortenedURL string) results.Upload {
+	return results.Upload{
+		File:        upload.FileName,
+		Bucket:      upload.Bucket,
+		Object:      upload.Object,
+		Public:      upload.Public,
+		Size:        upload.Size,
+		ContentType: upload.ContentType,
+		Checksum:    upload.Checksum,
+		MinioURL:    upload.URL,
+		ShortURL:    shortenedURL,
+		Expires:     results.TimeOrNil(upload.Expires),
+		DeleteAt:    results.TimeOrNil(upload.DeleteAt),
+		Existing:    upload.Existing,
+		Encryption:  upload.Encryption,
+	}
+}
+
+func sameFile(a string, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// A local file which is part of a batch upload
+type batchFile struct {
+	path string
+	// Object name relative to the batch prefix
+	name string
+	size int64
+}
+
+type batchResult struct {
+	file   batchFile
+	upload *storage.Upload
+	err    error
+}
+
+// Derives the prefix of a batch from its files, so an interrupted batch continues
+// with the same object names (and journals) when it is run again
+func batchPrefix(files []batchFile, public bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "public=%t\n", public)
+	for _, file := range files {
+		abs, err := filepath.Abs(file.path)
+		if err != nil {
+			abs = file.path
+		}
+		var modTime int64
+		if info, err := os.Stat(file.path); err == nil {
+			modTime = info.ModTime().UnixNano()
+		}
+		fmt.Fprintf(&b, "%s\x00%s\x00%d\x00%d\n", abs, file.name, file.size, modTime)
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(b.String())).String()
+}
+
+// Checks if the args describe a single file (or stdin) and not a batch
+func isSingleUpload(args []string) bool {
+	if len(args) != 1 {
+		return false
+	}
+	if args[0] == "-" {
+		return true
+	}
+	info, err := os.Stat(args[0])
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Expands files, directories (recursive) and globs to a list of files to upload
+func expandUploadArgs(args []string) ([]batchFile, error) {
+	var files []batchFile
+	used := make(map[string]int)
+	add := func(path string, name string, size int64) {
+		name = filepath.ToSlash(name)
+		used[name]++
+		if n := used[name]; n > 1 {
+			ext := filepath.Ext(name)
+			name = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
+		}
+		files = append(files, batchFile{path: path, name: name, size: size})
+	}
+
+	for _, arg := range args {
+		matches := []string{arg}
+		if _, err := os.Stat(arg); err != nil {
+			matches, err = filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob %s: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no such file or directory: %s", arg)
+			}
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("failed to stat %s: %w", match, err)
+			}
+			if info.Mode().IsRegular() {
+				add(match, filepath.Base(match), info.Size())
+				continue
+			}
+			if !info.IsDir() {
+				continue
+			}
+			base := filepath.Dir(filepath.Clean(match))
+			err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.Type().IsRegular() {
+					return nil
+				}
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				rel, err := filepath.Rel(base, path)
+				if err != nil {
+					return err
+				}
+				add(path, rel, info.Size())
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to walk directory %s: %w", match, err)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to upload")
+	}
+	return files, nil
+}
+
+// Uploads all files concurrently under the given prefix, results keep the order of files
+func uploadBatch(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	files []batchFile,
+	prefix string,
+	workers int,
+	public bool,
+	opts storage.UploadOptions,
+) []batchResult {
+	results := make([]batchResult, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				file := files[i]
+				objectName := prefix + "/" + file.name
+				upload, err := objectStore.UploadFileAs(ctx, file.path, objectName, public, opts)
+				results[i] = batchResult{file: file, upload: upload, err: err}
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// Uploads the index page for a batch upload, shortens its link and copies it to the clipboard
+func uploadManifest(
+	ctx context.Context,
+	objectStore storage.ObjectStore,
+	linkShortener shortener.Shortener,
+	prefix string,
+	entries []manifest.Entry,
+	public bool,
+	opts storage.UploadOptions,
+) (*storage.Upload, string, error) {
+	data, err := manifest.Render(fmt.Sprintf("%d shared file(s)", len(entries)), entries)
+	if err != nil {
+		return nil, "", err
+	}
+
+	upload, err := objectStore.UploadData(
+		ctx,
+		data,
+		prefix+"/"+manifest.FileName,
+		manifest.ContentType,
+		public,
+		opts,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	if upload.KeyRequired {
+		return upload, "", nil
+	}
+
+	shortenedURL, err := shortenAndCopy(ctx, linkShortener, upload.URL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return upload, shortenedURL, nil
 }
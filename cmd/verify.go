@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/progress"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <link | object key | file>",
+	Short: "Checks uploaded or downloaded content against its checksum",
+	Long: `Compares content with the SHA-256 checksum recorded when it was uploaded.
+
+For links (short links, MinIO links) and object keys the object is read from the
+storage and compared with the checksum stored in its metadata, or the one in your
+local history if the backend does not store checksums.
+
+For local files the checksum is looked up in your history, the file is fine if
+an upload with the same content exists and corrupt if the latest upload of a file
+with the same name has a different checksum.
+
+Exits with status 1 if the content does not match or could not be verified.`,
+	Example: `  minio-link verify https://short.example.com/abc
+  minio-link verify ./files/0b6c7a4e-2d1f-4c55-9a51-2f4f2c1f8d3e.png`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		startTime := time.Now()
+
+		input := args[0]
+		cfgPath := cmd.Flag("config").Value.String()
+		logsPath := cmd.Flag("logs").Value.String()
+		debug, err := cmd.Flags().GetBool("debug")
+		cobra.CheckErr(err)
+		progressMode, err := progress.ParseMode(cmd.Flag("progress").Value.String())
+		cobra.CheckErr(err)
+
+		if strings.Contains(logsPath, "./") {
+			exe, err := os.Executable()
+			cobra.CheckErr(err)
+
+			logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+		}
+
+		verifyLogger := log.NewLogger().
+			WithDirectory(logsPath).
+			WithName("verify").
+			WithDebug(debug).
+			WithConsoleOutput(debug)
+
+		historyStore, err := history.NewStore("")
+		if err != nil {
+			verifyLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		var res *results.VerifyResult
+		if info, err := os.Stat(input); err == nil && !storage.IsLink(input) {
+			if !info.Mode().IsRegular() {
+				verifyLogger.Error(fmt.Sprintf("%s is not a file", input))
+				os.Exit(1)
+			}
+			res, err = verifyFile(historyStore, input)
+			if err != nil {
+				verifyLogger.Error(err.Error())
+				os.Exit(1)
+			}
+		} else {
+			cfg, err := loadConfig(cfgPath)
+			if err != nil {
+				verifyLogger.Error(err.Error())
+				os.Exit(1)
+			}
+
+			if err := applySSEFlag(cmd, cfg); err != nil {
+				verifyLogger.Error(err.Error())
+				os.Exit(1)
+			}
+
+			verifyLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
+
+			if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+				!strings.Contains(endpoint, "https://") {
+				verifyLogger.Warn("storage not using SSL / TLS (INSECURE)")
+			}
+
+			if endpoint := cfg.ShortenerEndpoint(); endpoint != "" &&
+				!strings.Contains(endpoint, "https://") {
+				verifyLogger.Warn("shortener not using SSL / TLS (INSECURE)")
+			}
+
+			stopChan := make(chan bool, 1)
+			cancelChannel := make(chan os.Signal, 1)
+			signal.Notify(cancelChannel, os.Interrupt, syscall.SIGTERM)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			go func() {
+				select {
+				case sig := <-cancelChannel:
+					verifyLogger.Debug(fmt.Sprintf("received sys signal: %s", sig.String()))
+					cancel()
+					return
+				case <-stopChan:
+					verifyLogger.Debug("received stop signal")
+					return
+				}
+			}()
+
+			objectStore, err := newObjectStore(logsPath, debug, cfg)
+			if err != nil {
+				verifyLogger.Error(err.Error())
+				os.Exit(1)
+			}
+			objectStore.SetProgress(progressMode)
+
+			linkShortener, err := newShortener(logsPath, debug, cfg)
+			if err != nil {
+				verifyLogger.Error(err.Error())
+				os.Exit(1)
+			}
+
+			target, err := resolveLinkTarget(ctx, objectStore, linkShortener, historyStore, input)
+			if err != nil {
+				verifyLogger.Error(err.Error())
+				os.Exit(1)
+			}
+
+			verification, err := objectStore.VerifyObject(ctx, target.bucket, target.object)
+			if err != nil {
+				verifyLogger.Error(err.Error())
+				os.Exit(1)
+			}
+
+			res = &results.VerifyResult{
+				Input:    input,
+				Bucket:   verification.Bucket,
+				Object:   verification.Object,
+				Size:     verification.Size,
+				Expected: verification.Expected,
+				Actual:   verification.Actual,
+				Source:   checksumSourceMetadata,
+			}
+			if res.Expected == "" {
+				res.Expected = historyChecksum(historyStore, target.bucket, target.object)
+				res.Source = checksumSourceHistory
+			}
+			res.Status = verifyStatus(res.Expected, res.Actual)
+
+			close(stopChan)
+			close(cancelChannel)
+			verifyLogger.Debug("closed stop and cancel channels")
+		}
+		if res.Status == results.VerifyUnknown {
+			res.Source = ""
+		}
+
+		printResult(res)
+
+		verifyLogger.Debug(fmt.Sprintf("took %s", time.Since(startTime).String()))
+		switch res.Status {
+		case results.VerifyMismatch:
+			verifyLogger.Error(fmt.Sprintf(
+				"checksum mismatch for %s: expected %s, got %s",
+				input,
+				res.Expected,
+				res.Actual,
+			))
+			os.Exit(1)
+		case results.VerifyUnknown:
+			verifyLogger.Error(fmt.Sprintf("no checksum recorded for %s", input))
+			os.Exit(1)
+		}
+		verifyLogger.Info("Verifying done")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
+	verifyCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	verifyCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	verifyCmd.Flags().
+		String("progress", "auto", "Sets the progress output (auto, bar, json, none)")
+	verifyCmd.Flags().
+		String("sse", "", "Sets the SSE-C key file (c:<keyfile>) of server side encrypted objects")
+}
+
+// Checks a local file against the checksums of uploads in the history
+func verifyFile(historyStore *history.Store, filePath string) (*results.VerifyResult, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	checksum, err := storage.FileChecksum(filePath)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := historyStore.List(history.Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &results.VerifyResult{
+		Input:  filePath,
+		File:   filePath,
+		Size:   info.Size(),
+		Actual: checksum,
+		Source: checksumSourceHistory,
+	}
+	// Downloads are named after the object, uploads keep their file name
+	name := filepath.Base(filePath)
+	var latest *history.Entry
+	for _, entry := range entries {
+		if entry.Checksum == "" {
+			continue
+		}
+		if entry.Checksum == checksum {
+			res.Bucket = entry.Bucket
+			res.Object = entry.Object
+			res.Expected = entry.Checksum
+			res.Status = results.VerifyOK
+			return res, nil
+		}
+		if latest == nil && (entry.FileName == name || path.Base(entry.Object) == name) {
+			latest = &entry
+		}
+	}
+	if latest != nil {
+		res.Bucket = latest.Bucket
+		res.Object = latest.Object
+		res.Expected = latest.Checksum
+	}
+	res.Status = verifyStatus(res.Expected, res.Actual)
+	return res, nil
+}
+
+// Returns the checksum of the newest upload of the object in the history, if any
+func historyChecksum(historyStore *history.Store, bucket string, object string) string {
+	entries, err := historyStore.List(history.Filter{})
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.Bucket == bucket && entry.Object == object && entry.Checksum != "" {
+			return entry.Checksum
+		}
+	}
+	return ""
+}
+
+func verifyStatus(expected string, actual string) string {
+	switch expected {
+	case "":
+		return results.VerifyUnknown
+	case actual:
+		return results.VerifyOK
+	}
+	return results.VerifyMismatch
+}
+
+const (
+	checksumSourceMetadata string = "metadata"
+	checksumSourceHistory  string = "history"
+)
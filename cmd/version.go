@@ -1,9 +1,9 @@
 package cmd
 
 import (
-	"fmt"
 	"runtime"
 
+	"github.com/devusSs/minio-link/internal/results"
 	"github.com/spf13/cobra"
 )
 
@@ -21,15 +21,14 @@ If you do not have version or build information please make sure to
 either download an already compiled release or build the application
 yourself properly.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("minio-link by devusSs")
-		fmt.Println("---------------------")
-		fmt.Printf("Build version:\t\t%s\n", BuildVersion)
-		fmt.Printf("Build date:\t\t%s\n", BuildDate)
-		fmt.Printf("Build Git commit:\t%s\n", BuildGitCommit)
-		fmt.Println("")
-		fmt.Printf("Build Go OS:\t\t%s\n", runtime.GOOS)
-		fmt.Printf("Build Go arch:\t\t%s\n", runtime.GOARCH)
-		fmt.Printf("Build Go version:\t%s\n", runtime.Version())
+		printResult(&results.VersionResult{
+			Version:   BuildVersion,
+			Date:      BuildDate,
+			GitCommit: BuildGitCommit,
+			GoOS:      runtime.GOOS,
+			GoArch:    runtime.GOARCH,
+			GoVersion: runtime.Version(),
+		})
 	},
 }
 
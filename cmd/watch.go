@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/history"
+	"github.com/devusSs/minio-link/internal/results"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/internal/watch"
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/progress"
+	"github.com/devusSs/minio-link/pkg/system"
+	"github.com/devusSs/minio-link/pkg/timeparse"
+	"github.com/spf13/cobra"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <directory>",
+	Short: "Uploads new files in a directory automatically",
+	Long: `Watches a directory and uploads every new or changed file once it has been
+completely written (its size did not change for --settle), shortens the link
+and copies it to the clipboard like the upload command.
+
+Files are matched against --include and --exclude (globs matched against the
+file name and the path relative to the directory). Uploaded files are recorded
+so they are not uploaded again after a restart, files which were added while
+watch was not running are uploaded on start unless --ignore-existing is set.`,
+	Example: `  minio-link watch ~/Pictures/Screenshots --include "*.png" --notify
+  minio-link watch ./exports -r --private --expires 3d`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfgPath := cmd.Flag("config").Value.String()
+		logsPath := cmd.Flag("logs").Value.String()
+		debug, err := cmd.Flags().GetBool("debug")
+		cobra.CheckErr(err)
+		private, err := cmd.Flags().GetBool("private")
+		cobra.CheckErr(err)
+		expires := cmd.Flag("expires").Value.String()
+		if expires != "" {
+			_, _, err := timeparse.ParseTime(expires, time.Now())
+			cobra.CheckErr(err)
+		}
+		include, err := cmd.Flags().GetStringSlice("include")
+		cobra.CheckErr(err)
+		exclude, err := cmd.Flags().GetStringSlice("exclude")
+		cobra.CheckErr(err)
+		recursive, err := cmd.Flags().GetBool("recursive")
+		cobra.CheckErr(err)
+		settle, err := cmd.Flags().GetDuration("settle")
+		cobra.CheckErr(err)
+		notify, err := cmd.Flags().GetBool("notify")
+		cobra.CheckErr(err)
+		ignoreExisting, err := cmd.Flags().GetBool("ignore-existing")
+		cobra.CheckErr(err)
+		forceNew, err := cmd.Flags().GetBool("force-new")
+		cobra.CheckErr(err)
+
+		dir, err := filepath.Abs(args[0])
+		cobra.CheckErr(err)
+
+		if strings.Contains(logsPath, "./") {
+			exe, err := os.Executable()
+			cobra.CheckErr(err)
+
+			logsPath = filepath.Join(filepath.Dir(exe), logsPath)
+		}
+
+		watchLogger := log.NewLogger().
+			WithDirectory(logsPath).
+			WithName("watch").
+			WithDebug(debug).
+			WithConsoleOutput(debug)
+
+		cfg, err := loadConfig(cfgPath)
+		if err != nil {
+			watchLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		if err := applySSEFlag(cmd, cfg); err != nil {
+			watchLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		watchLogger.Debug(fmt.Sprintf("loaded config: %v", cfg))
+
+		if endpoint := cfg.StorageEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			watchLogger.Warn("storage not using SSL / TLS (INSECURE)")
+		}
+
+		if endpoint := cfg.ShortenerEndpoint(); endpoint != "" &&
+			!strings.Contains(endpoint, "https://") {
+			watchLogger.Warn("shortener not using SSL / TLS (INSECURE)")
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		objectStore, err := newObjectStore(logsPath, debug, cfg)
+		if err != nil {
+			watchLogger.Error(err.Error())
+			os.Exit(1)
+		}
+		// Bars of consecutive uploads would mix with the printed results
+		objectStore.SetProgress(progress.ModeNone)
+
+		linkShortener, err := newShortener(logsPath, debug, cfg)
+		if err != nil {
+			watchLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		historyStore, err := history.NewStore("")
+		if err != nil {
+			watchLogger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		state, err := watch.LoadState(dir)
+		if err != nil {
+			watchLogger.Error(err.Error())
+			os.Exit(1)
+		}
+		watchLogger.Debug(fmt.Sprintf("using watch state: %s", state.Path()))
+
+		watcher, err := watch.New(dir, watch.Options{
+			Recursive: recursive,
+			Include:   include,
+			Exclude:   exclude,
+			Settle:    settle,
+		}, watchLogger)
+		if err != nil {
+			watchLogger.Error(err.Error())
+			os.Exit(1)
+		}
+		defer watcher.Close()
+
+		sendNotification := func(title string, message string) {
+			if !notify {
+				return
+			}
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+				defer cancel()
+				if err := system.Notify(ctx, title, message); err != nil {
+					watchLogger.Warn(err.Error())
+				}
+			}()
+		}
+
+		process := func(path string) {
+			info, err := os.Stat(path)
+			if err != nil {
+				watchLogger.Warn(fmt.Sprintf("skipping %s: %s", path, err))
+				return
+			}
+			if state.Done(path, info) {
+				watchLogger.Debug(fmt.Sprintf("already uploaded: %s", path))
+				return
+			}
+
+			// Relative expiries start with every upload
+			opts := storage.UploadOptions{ForceNew: forceNew}
+			if expires != "" {
+				opts.Expires, _, err = timeparse.ParseTime(expires, time.Now())
+				if err == nil && !opts.Expires.After(time.Now()) {
+					err = fmt.Errorf("expiry %s is in the past", expires)
+				}
+				if err != nil {
+					watchLogger.Error(err.Error())
+					return
+				}
+			}
+
+			uploaded, shortenedURL, err := uploadAndShorten(
+				ctx,
+				objectStore,
+				linkShortener,
+				historyStore,
+				path,
+				"",
+				!private,
+				opts,
+			)
+			if err != nil {
+				watchLogger.Error(fmt.Sprintf("failed to upload %s: %s", path, err))
+				sendNotification("Upload failed", fmt.Sprintf("%s: %s", filepath.Base(path), err))
+				return
+			}
+			if err := recordUpload(historyStore, uploaded, shortenedURL); err != nil {
+				watchLogger.Warn(fmt.Sprintf("failed to record upload in history: %s", err))
+			}
+			if err := state.Add(path, info, shortenedURL); err != nil {
+				watchLogger.Warn(fmt.Sprintf("failed to record %s as uploaded: %s", path, err))
+			}
+
+			watchLogger.Info(fmt.Sprintf("Uploaded %s: %s", path, shortenedURL))
+			upload := newUploadResult(uploaded, shortenedURL)
+			upload.File = path
+			printResult(&results.UploadResult{
+				Files:    []results.Upload{upload},
+				ShortURL: shortenedURL,
+			})
+			sendNotification(
+				"Uploaded "+filepath.Base(path),
+				fmt.Sprintf("%s (copied to clipboard)", shortenedURL),
+			)
+		}
+
+		existing, err := watcher.Existing()
+		if err != nil {
+			watchLogger.Error(err.Error())
+			os.Exit(1)
+		}
+		for _, path := range existing {
+			if ctx.Err() != nil {
+				break
+			}
+			if !ignoreExisting {
+				process(path)
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil || state.Done(path, info) {
+				continue
+			}
+			if err := state.Add(path, info, ""); err != nil {
+				watchLogger.Warn(fmt.Sprintf("failed to record %s as uploaded: %s", path, err))
+			}
+		}
+
+		watchLogger.Info(fmt.Sprintf("Watching %s", dir))
+		fmt.Fprintf(os.Stderr, "Watching %s (press Ctrl+C to stop)\n", dir)
+
+		watcher.Run(ctx, process)
+
+		watchLogger.Info("Watching stopped")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringP("config", "c", "", "Sets the path of our env file if wanted")
+	watchCmd.Flags().StringP("logs", "l", "./logs", "Sets the path for our logs file")
+	watchCmd.Flags().BoolP("debug", "d", false, "Sets the debug mode for our application")
+	watchCmd.Flags().
+		BoolP("private", "p", false, "Sets the bucket and therefor uploaded files to private")
+	watchCmd.Flags().
+		StringP("expires", "e", "", "Deletes files after a duration (3d) or at a date (2026-12-01)")
+	watchCmd.Flags().
+		StringSliceP("include", "i", nil, "Only uploads files matching the globs (default all)")
+	watchCmd.Flags().
+		StringSliceP("exclude", "x", defaultWatchExcludes, "Never uploads files matching the globs")
+	watchCmd.Flags().BoolP("recursive", "r", false, "Also watches sub directories")
+	watchCmd.Flags().
+		Duration("settle", watch.DefaultSettle, "Sets how long a file must be unchanged to upload")
+	watchCmd.Flags().BoolP("notify", "n", false, "Shows a desktop notification for every upload")
+	watchCmd.Flags().
+		Bool("ignore-existing", false, "Records files already in the directory without uploading")
+	watchCmd.Flags().
+		Bool("force-new", false, "Uploads even if the same content has been uploaded before")
+	watchCmd.Flags().
+		String("sse", "", "Sets the server side encryption (none, s3, kms[:<key-id>], c:<keyfile>)")
+}
+
+var (
+	// Hidden files, editor swap files and partial downloads
+	defaultWatchExcludes = []string{".*", "*~", "*.tmp", "*.part", "*.crdownload", "*.swp"}
+)
+
+const (
+	notifyTimeout time.Duration = 10 * time.Second
+)
@@ -2,6 +2,9 @@ package environment
 
 import (
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v9"
@@ -10,34 +13,111 @@ import (
 
 // EnvConfig is a struct that holds all the environment variables
 type EnvConfig struct {
-	MinioEndpoint      string        `env:"MINIO_ENDPOINT"       envDefault:"localhost:9000"`
-	MinioAccessKey     string        `env:"MINIO_ACCESS_KEY"`
-	MinioAccessSecret  string        `env:"MINIO_ACCESS_SECRET"`
-	MinioUseSSL        bool          `env:"MINIO_USE_SSL"        envDefault:"false"`
-	MinioBucketName    string        `env:"MINIO_BUCKET_NAME"    envDefault:"minio-link"`
-	MinioRegion        string        `env:"MINIO_REGION"         envDefault:"us-east-1"`
-	MinioObjectLocking bool          `env:"MINIO_OBJECT_LOCKING" envDefault:"false"`
-	MinioDefaultExpiry time.Duration `env:"MINIO_DEFAULT_EXPIRY" envDefault:"168h"`
-	YourlsEndpoint     string        `env:"YOURLS_ENDPOINT"      envDefault:"http://localhost:8080"`
-	YourlsSignatureKey string        `env:"YOURLS_SIGNATURE_KEY"`
+	Storage              string        `env:"STORAGE"              envDefault:"minio"`
+	MinioEndpoint        string        `env:"MINIO_ENDPOINT"       envDefault:"localhost:9000"`
+	MinioAccessKey       string        `env:"MINIO_ACCESS_KEY"     envDefault:""`
+	MinioAccessSecret    string        `env:"MINIO_ACCESS_SECRET"  envDefault:""`
+	MinioUseSSL          bool          `env:"MINIO_USE_SSL"        envDefault:"false"`
+	MinioBucketName      string        `env:"MINIO_BUCKET_NAME"    envDefault:"minio-link"`
+	MinioRegion          string        `env:"MINIO_REGION"         envDefault:"us-east-1"`
+	MinioObjectLocking   bool          `env:"MINIO_OBJECT_LOCKING" envDefault:"false"`
+	MinioSSE             string        `env:"MINIO_SSE"            envDefault:""`
+	MinioBucketSSE       bool          `env:"MINIO_BUCKET_SSE"     envDefault:"false"`
+	MinioDefaultExpiry   time.Duration `env:"MINIO_DEFAULT_EXPIRY" envDefault:"168h"`
+	FilesystemDirectory  string        `env:"FS_DIRECTORY"         envDefault:""`
+	FilesystemBaseURL    string        `env:"FS_BASE_URL"          envDefault:""`
+	FilesystemLinkSecret string        `env:"FS_LINK_SECRET"       envDefault:""`
+	WebDAVEndpoint       string        `env:"WEBDAV_ENDPOINT"      envDefault:""`
+	WebDAVUsername       string        `env:"WEBDAV_USERNAME"      envDefault:""`
+	WebDAVPassword       string        `env:"WEBDAV_PASSWORD"      envDefault:""`
+	WebDAVBaseURL        string        `env:"WEBDAV_BASE_URL"      envDefault:""`
+	WebDAVLinkSecret     string        `env:"WEBDAV_LINK_SECRET"   envDefault:""`
+	Shortener            string        `env:"SHORTENER"            envDefault:"yourls"`
+	YourlsEndpoint       string        `env:"YOURLS_ENDPOINT"      envDefault:"http://localhost:8080"`
+	YourlsSignatureKey   string        `env:"YOURLS_SIGNATURE_KEY" envDefault:""`
+	YourlsAuth           string        `env:"YOURLS_AUTH"          envDefault:"timestamp"`
+	YourlsUsername       string        `env:"YOURLS_USERNAME"      envDefault:""`
+	YourlsPassword       string        `env:"YOURLS_PASSWORD"      envDefault:""`
+	ShlinkEndpoint       string        `env:"SHLINK_ENDPOINT"      envDefault:"http://localhost:8081"`
+	ShlinkAPIKey         string        `env:"SHLINK_API_KEY"       envDefault:""`
+	KuttEndpoint         string        `env:"KUTT_ENDPOINT"        envDefault:"https://kutt.it"`
+	KuttAPIKey           string        `env:"KUTT_API_KEY"         envDefault:""`
+	SecretStore          string        `env:"SECRET_STORE"         envDefault:"auto"`
+	SecretFile           string        `env:"SECRET_FILE"          envDefault:""`
+	SecretPassphrase     string        `env:"SECRET_PASSPHRASE"    envDefault:""`
+	ServeTokens          string        `env:"SERVE_TOKENS"         envDefault:""`
+
+	// Name of the profile the config was loaded from, empty if none was used
+	Profile string
+}
+
+// StorageEndpoint returns the endpoint of the configured storage backend,
+// empty for the local filesystem
+func (e *EnvConfig) StorageEndpoint() string {
+	switch strings.ToLower(e.Storage) {
+	case "filesystem":
+		return ""
+	case "webdav":
+		return e.WebDAVEndpoint
+	}
+	if e.MinioUseSSL {
+		return "https://" + e.MinioEndpoint
+	}
+	return "http://" + e.MinioEndpoint
+}
+
+// ShortenerEndpoint returns the endpoint of the configured url shortener,
+// empty if no shortener is used
+func (e *EnvConfig) ShortenerEndpoint() string {
+	switch strings.ToLower(e.Shortener) {
+	case "shlink":
+		return e.ShlinkEndpoint
+	case "kutt":
+		return e.KuttEndpoint
+	case "none":
+		return ""
+	}
+	return e.YourlsEndpoint
 }
 
 // Enables printing of config without sensitive data
 func (e *EnvConfig) String() string {
 	return fmt.Sprintf(
-		"minio endpoint: %s, minio use ssl: %t, minio bucket name: %s, minio region: %s, minio object locking: %t, yourls url: %s",
-		e.MinioEndpoint,
-		e.MinioUseSSL,
+		"profile: %s, storage: %s, storage url: %s, minio bucket name: %s, minio region: %s, minio object locking: %t, minio sse: %s, shortener: %s, shortener url: %s",
+		e.Profile,
+		e.Storage,
+		e.StorageEndpoint(),
 		e.MinioBucketName,
 		e.MinioRegion,
 		e.MinioObjectLocking,
-		e.YourlsEndpoint,
+		e.MinioSSE,
+		e.Shortener,
+		e.ShortenerEndpoint(),
 	)
 }
 
 // Load loads the environment variables from environment
 // or given files if specified
 func Load(envFiles ...string) (*EnvConfig, error) {
+	return LoadProfile(nil, envFiles...)
+}
+
+// LoadProfile works like Load but uses the given profile values (keys without
+// the LINK_ prefix) for every variable which is not set in the environment
+func LoadProfile(profile map[string]string, envFiles ...string) (*EnvConfig, error) {
+	cfg, err := LoadUnresolved(profile, envFiles...)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ResolveSecrets(); err != nil {
+		return nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+	return cfg, nil
+}
+
+// LoadUnresolved works like LoadProfile but keeps secret references (e.g. keyring:name)
+// as they are, used to edit configs without exposing the secrets
+func LoadUnresolved(profile map[string]string, envFiles ...string) (*EnvConfig, error) {
 	for _, envFile := range envFiles {
 		if envFile != "" {
 			if err := godotenv.Load(envFile); err != nil {
@@ -45,13 +125,73 @@ func Load(envFiles ...string) (*EnvConfig, error) {
 			}
 		}
 	}
+	opts := options
+	if len(profile) > 0 {
+		var err error
+		if opts.Environment, err = profileEnvironment(profile); err != nil {
+			return nil, err
+		}
+		for _, kv := range os.Environ() {
+			if key, value, ok := strings.Cut(kv, "="); ok {
+				opts.Environment[key] = value
+			}
+		}
+	}
+	return parse(opts)
+}
+
+// ParseProfile parses only the given profile values, ignoring the environment
+func ParseProfile(profile map[string]string) (*EnvConfig, error) {
+	environment, err := profileEnvironment(profile)
+	if err != nil {
+		return nil, err
+	}
+	opts := options
+	opts.Environment = environment
+	return parse(opts)
+}
+
+func parse(opts env.Options) (*EnvConfig, error) {
 	var cfg EnvConfig
-	if err := env.ParseWithOptions(&cfg, options); err != nil {
-		return nil, fmt.Errorf("failed to parse environment: %s", err)
+	if err := env.ParseWithOptions(&cfg, opts); err != nil {
+		return nil, fmt.Errorf("failed to parse environment: %w", err)
 	}
 	return &cfg, nil
 }
 
+// Turns profile values into prefixed environment variables
+func profileEnvironment(profile map[string]string) (map[string]string, error) {
+	environment := make(map[string]string, len(profile))
+	for key, value := range profile {
+		name, err := NormalizeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		environment[options.Prefix+name] = value
+	}
+	return environment, nil
+}
+
+// Keys returns the names of all supported variables without the LINK_ prefix
+func Keys() []string {
+	fields := Fields()
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		keys = append(keys, field.Key)
+	}
+	return keys
+}
+
+// NormalizeKey turns "minio_endpoint", "MINIO_ENDPOINT" or "LINK_MINIO_ENDPOINT"
+// into "MINIO_ENDPOINT" and checks if the variable exists
+func NormalizeKey(key string) (string, error) {
+	name := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(key)), options.Prefix)
+	if !slices.Contains(Keys(), name) {
+		return "", fmt.Errorf("unknown config key: %s", key)
+	}
+	return name, nil
+}
+
 var (
 	options = env.Options{
 		Prefix:          "LINK_",
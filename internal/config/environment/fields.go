@@ -0,0 +1,368 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v9"
+)
+
+// Field describes a single config variable
+type Field struct {
+	// Name without the LINK_ prefix
+	Key         string
+	Default     string
+	Description string
+	// Secret values are never printed
+	Secret bool
+	// Storage or shortener backend the field is used by, empty if used by all
+	Backend string
+}
+
+// FieldError describes an invalid or missing config variable
+type FieldError struct {
+	// Name without the LINK_ prefix, empty if the error does not belong to a variable
+	Key     string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	if e.Key == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s%s: %s", options.Prefix, e.Key, e.Message)
+}
+
+// Fields returns all supported variables in the order of EnvConfig
+func Fields() []Field {
+	t := reflect.TypeOf(EnvConfig{})
+	fields := make([]Field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		info := fieldInfo[key]
+		fields = append(fields, Field{
+			Key:         key,
+			Default:     t.Field(i).Tag.Get("envDefault"),
+			Description: info.description,
+			Secret:      info.secret,
+			Backend:     info.backend,
+		})
+	}
+	return fields
+}
+
+// Value returns the value of a variable formatted like it would be set in the environment
+func (e *EnvConfig) Value(key string) string {
+	v := reflect.ValueOf(e).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("env") != key {
+			continue
+		}
+		switch value := v.Field(i).Interface().(type) {
+		case time.Duration:
+			return formatDuration(value)
+		default:
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+// Used reports if a field is used by the configured storage and shortener backends
+func (e *EnvConfig) Used(field Field) bool {
+	return field.Backend == "" ||
+		field.Backend == strings.ToLower(e.Storage) ||
+		field.Backend == strings.ToLower(e.Shortener)
+}
+
+// Validate checks the values needed by the configured backends,
+// it does not check if the services are actually reachable
+func (e *EnvConfig) Validate() []FieldError {
+	var errs []FieldError
+	add := func(key string, format string, args ...any) {
+		errs = append(errs, FieldError{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+	required := func(key string, value string) {
+		if strings.TrimSpace(value) == "" {
+			add(key, "required for %s", e.backendOf(key))
+		}
+	}
+	httpURL := func(key string, value string) {
+		if value == "" {
+			return
+		}
+		if u, err := url.Parse(value); err != nil ||
+			(u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(key, "must be an http(s) url, got %q", value)
+		}
+	}
+
+	if !slices.Contains(storageBackends, strings.ToLower(e.Storage)) {
+		add("STORAGE", "unsupported storage %q (supported: %s)",
+			e.Storage, strings.Join(storageBackends, ", "))
+	}
+	if !slices.Contains(shortenerBackends, strings.ToLower(e.Shortener)) {
+		add("SHORTENER", "unsupported shortener %q (supported: %s)",
+			e.Shortener, strings.Join(shortenerBackends, ", "))
+	}
+	if err := validateBucketName(e.MinioBucketName); err != nil {
+		add("MINIO_BUCKET_NAME", "%s", err)
+	}
+	if !slices.Contains(secretStores, strings.ToLower(e.SecretStore)) {
+		add("SECRET_STORE", "unsupported secret store %q (supported: %s)",
+			e.SecretStore, strings.Join(secretStores, ", "))
+	}
+	if e.MinioDefaultExpiry <= 0 || e.MinioDefaultExpiry > maxLinkExpiry {
+		add("MINIO_DEFAULT_EXPIRY", "must be between 1s and %s", formatDuration(maxLinkExpiry))
+	}
+
+	switch strings.ToLower(e.Storage) {
+	case "minio":
+		required("MINIO_ENDPOINT", e.MinioEndpoint)
+		if strings.Contains(e.MinioEndpoint, "://") {
+			add("MINIO_ENDPOINT", "must be host[:port] without scheme, use MINIO_USE_SSL instead")
+		}
+		required("MINIO_ACCESS_KEY", e.MinioAccessKey)
+		required("MINIO_ACCESS_SECRET", e.MinioAccessSecret)
+		sse, err := ParseSSE(e.MinioSSE)
+		if err != nil {
+			add("MINIO_SSE", "%s", err)
+		}
+		if sse.Type == SSEC && !e.MinioUseSSL {
+			add("MINIO_SSE", "SSE-C keys are only sent via https, enable MINIO_USE_SSL")
+		}
+		if e.MinioBucketSSE && sse.Type != SSES3 && sse.Type != SSEKMS {
+			add("MINIO_BUCKET_SSE", "requires MINIO_SSE s3 or kms[:<key-id>]")
+		}
+	case "filesystem":
+		required("FS_DIRECTORY", e.FilesystemDirectory)
+		required("FS_BASE_URL", e.FilesystemBaseURL)
+		httpURL("FS_BASE_URL", e.FilesystemBaseURL)
+	case "webdav":
+		required("WEBDAV_ENDPOINT", e.WebDAVEndpoint)
+		httpURL("WEBDAV_ENDPOINT", e.WebDAVEndpoint)
+		httpURL("WEBDAV_BASE_URL", e.WebDAVBaseURL)
+	}
+
+	switch strings.ToLower(e.Shortener) {
+	case "yourls":
+		required("YOURLS_ENDPOINT", e.YourlsEndpoint)
+		httpURL("YOURLS_ENDPOINT", e.YourlsEndpoint)
+		switch strings.ToLower(e.YourlsAuth) {
+		case YourlsAuthTimestamp, YourlsAuthSignature:
+			required("YOURLS_SIGNATURE_KEY", e.YourlsSignatureKey)
+		case YourlsAuthPassword:
+			required("YOURLS_USERNAME", e.YourlsUsername)
+			required("YOURLS_PASSWORD", e.YourlsPassword)
+		default:
+			add("YOURLS_AUTH", "unsupported auth mode %q (supported: %s)",
+				e.YourlsAuth, strings.Join(yourlsAuthModes, ", "))
+		}
+	case "shlink":
+		required("SHLINK_ENDPOINT", e.ShlinkEndpoint)
+		httpURL("SHLINK_ENDPOINT", e.ShlinkEndpoint)
+		required("SHLINK_API_KEY", e.ShlinkAPIKey)
+	case "kutt":
+		required("KUTT_ENDPOINT", e.KuttEndpoint)
+		httpURL("KUTT_ENDPOINT", e.KuttEndpoint)
+		required("KUTT_API_KEY", e.KuttAPIKey)
+	}
+	return errs
+}
+
+// FieldErrors splits an error returned by Load, LoadProfile or ParseProfile
+// into errors per variable
+func FieldErrors(err error) []FieldError {
+	var aggregate env.AggregateError
+	if errors.As(err, &aggregate) {
+		return aggregateFieldErrors(aggregate)
+	}
+	var errs []FieldError
+	collectFieldErrors(err, &errs)
+	if len(errs) == 0 {
+		return []FieldError{{Message: err.Error()}}
+	}
+	return errs
+}
+
+// Walks wrapped and joined errors (e.g. from ResolveSecrets)
+func collectFieldErrors(err error, errs *[]FieldError) {
+	switch e := err.(type) {
+	case FieldError:
+		*errs = append(*errs, e)
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			collectFieldErrors(inner, errs)
+		}
+	case interface{ Unwrap() error }:
+		collectFieldErrors(e.Unwrap(), errs)
+	}
+}
+
+func aggregateFieldErrors(aggregate env.AggregateError) []FieldError {
+	t := reflect.TypeOf(EnvConfig{})
+	errs := make([]FieldError, 0, len(aggregate.Errors))
+	for _, e := range aggregate.Errors {
+		var parseErr env.ParseError
+		if errors.As(e, &parseErr) {
+			if field, ok := t.FieldByName(parseErr.Name); ok {
+				errs = append(errs, FieldError{
+					Key:     field.Tag.Get("env"),
+					Message: fmt.Sprintf("invalid %s: %s", parseErr.Type, parseErr.Err),
+				})
+				continue
+			}
+		}
+		errs = append(errs, FieldError{Message: e.Error()})
+	}
+	return errs
+}
+
+// Returns the backend a field belongs to, used for error messages
+func (e *EnvConfig) backendOf(key string) string {
+	if backend := fieldInfo[key].backend; backend != "" {
+		return backend
+	}
+	return "all backends"
+}
+
+// Checks the S3 bucket naming rules (which we also use for directories)
+func validateBucketName(name string) error {
+	if len(name) < 3 || len(name) > 63-len("-private") {
+		return fmt.Errorf("must be between 3 and %d characters", 63-len("-private"))
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '.' {
+			return fmt.Errorf("may only contain lowercase letters, numbers, dots and hyphens")
+		}
+	}
+	if strings.HasPrefix(name, "-") || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("must start with a letter or number")
+	}
+	return nil
+}
+
+// Formats durations without trailing zero units (168h instead of 168h0m0s)
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+var (
+	storageBackends   = []string{"minio", "filesystem", "webdav"}
+	shortenerBackends = []string{"yourls", "shlink", "kutt", "none"}
+	secretStores      = []string{"auto", "keyring", "file"}
+	yourlsAuthModes   = []string{YourlsAuthTimestamp, YourlsAuthSignature, YourlsAuthPassword}
+
+	fieldInfo = map[string]struct {
+		description string
+		secret      bool
+		backend     string
+	}{
+		"STORAGE":             {description: "Storage backend (minio, filesystem, webdav)"},
+		"MINIO_ENDPOINT":      {description: "MinIO host[:port] without scheme", backend: "minio"},
+		"MINIO_ACCESS_KEY":    {description: "MinIO access key", backend: "minio"},
+		"MINIO_ACCESS_SECRET": {description: "MinIO secret key", secret: true, backend: "minio"},
+		"MINIO_USE_SSL":       {description: "Connect to MinIO via https", backend: "minio"},
+		"MINIO_BUCKET_NAME":   {description: "Bucket (or directory) name for uploads"},
+		"MINIO_REGION":        {description: "Region for newly created buckets", backend: "minio"},
+		"MINIO_OBJECT_LOCKING": {
+			description: "Enable object locking for new buckets",
+			backend:     "minio",
+		},
+		"MINIO_SSE": {
+			description: "Server side encryption (none, s3, kms[:<key-id>], c:<keyfile>)",
+			backend:     "minio",
+		},
+		"MINIO_BUCKET_SSE": {
+			description: "Set MINIO_SSE (s3 or kms) as default encryption of new buckets",
+			backend:     "minio",
+		},
+		"MINIO_DEFAULT_EXPIRY": {description: "Lifetime of private links (max 168h)"},
+		"FS_DIRECTORY": {
+			description: "Directory files are stored in",
+			backend:     "filesystem",
+		},
+		"FS_BASE_URL": {
+			description: "URL the directory is served under",
+			backend:     "filesystem",
+		},
+		"FS_LINK_SECRET": {
+			description: "Secret signing private links",
+			secret:      true,
+			backend:     "filesystem",
+		},
+		"WEBDAV_ENDPOINT": {description: "WebDAV server url", backend: "webdav"},
+		"WEBDAV_USERNAME": {description: "WebDAV username", backend: "webdav"},
+		"WEBDAV_PASSWORD": {description: "WebDAV password", secret: true, backend: "webdav"},
+		"WEBDAV_BASE_URL": {
+			description: "Public url of the WebDAV files (default endpoint)",
+			backend:     "webdav",
+		},
+		"WEBDAV_LINK_SECRET": {
+			description: "Secret signing private links",
+			secret:      true,
+			backend:     "webdav",
+		},
+		"SHORTENER":       {description: "Url shortener (yourls, shlink, kutt, none)"},
+		"YOURLS_ENDPOINT": {description: "YOURLS url", backend: "yourls"},
+		"YOURLS_SIGNATURE_KEY": {
+			description: "YOURLS signature token",
+			secret:      true,
+			backend:     "yourls",
+		},
+		"YOURLS_AUTH": {
+			description: "YOURLS auth mode (timestamp, signature, password)",
+			backend:     "yourls",
+		},
+		"YOURLS_USERNAME": {description: "YOURLS username (password auth)", backend: "yourls"},
+		"YOURLS_PASSWORD": {
+			description: "YOURLS password (password auth)",
+			secret:      true,
+			backend:     "yourls",
+		},
+		"SHLINK_ENDPOINT": {description: "Shlink url", backend: "shlink"},
+		"SHLINK_API_KEY":  {description: "Shlink API key", secret: true, backend: "shlink"},
+		"KUTT_ENDPOINT":   {description: "Kutt url", backend: "kutt"},
+		"SECRET_STORE": {
+			description: "Store for keyring: references (auto, keyring, file)",
+		},
+		"SECRET_FILE": {description: "Encrypted secret file (default in config directory)"},
+		"SECRET_PASSPHRASE": {
+			description: "Passphrase of the encrypted secret file (asked if empty)",
+			secret:      true,
+		},
+		"KUTT_API_KEY": {description: "Kutt API key", secret: true, backend: "kutt"},
+		"SERVE_TOKENS": {
+			description: "API tokens for serve (comma separated, optionally name=token)",
+			secret:      true,
+		},
+	}
+)
+
+// Auth modes of YOURLS, exported as yourls.Auth* (yourls imports this package)
+const (
+	YourlsAuthTimestamp string = "timestamp"
+	YourlsAuthSignature string = "signature"
+	YourlsAuthPassword  string = "password"
+)
+
+const (
+	// Maximum lifetime of presigned links supported by S3
+	maxLinkExpiry time.Duration = 7 * 24 * time.Hour
+)
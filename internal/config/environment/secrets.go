@@ -0,0 +1,150 @@
+package environment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/config/secrets"
+)
+
+// PassphrasePrompt is asked for the passphrase of the encrypted secret file
+// if LINK_SECRET_PASSPHRASE is not set, nil means the passphrase is required
+var PassphrasePrompt secrets.PassphraseFunc
+
+// Prefixes of values which are resolved at load time instead of being used as they are
+const (
+	// Secret with the given name in the configured store (keyring or encrypted file)
+	RefKeyring string = "keyring:"
+	// Content of the given file (e.g. Docker / systemd secrets)
+	RefFile string = "file:"
+	// Output of the given shell command (e.g. "cmd:pass show minio")
+	RefCommand string = "cmd:"
+)
+
+// IsSecretRef reports if the value is resolved at load time
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, RefKeyring) ||
+		strings.HasPrefix(value, RefFile) ||
+		strings.HasPrefix(value, RefCommand)
+}
+
+// OpenSecretStore opens the secret store chosen via LINK_SECRET_STORE
+func (e *EnvConfig) OpenSecretStore() (secrets.Store, error) {
+	return secrets.New(e.SecretStore, e.SecretFile, e.passphrase)
+}
+
+// ResolvePassphrase replaces a file: or cmd: reference in LINK_SECRET_PASSPHRASE,
+// it has to be resolved before the secret store is opened
+func (e *EnvConfig) ResolvePassphrase() error {
+	// The passphrase can not live in the store it unlocks
+	if strings.HasPrefix(e.SecretPassphrase, RefKeyring) {
+		return FieldError{
+			Key:     "SECRET_PASSPHRASE",
+			Message: "can not be stored in the secret store, use file: or cmd:",
+		}
+	}
+	if IsSecretRef(e.SecretPassphrase) {
+		value, err := e.resolve(nil, e.SecretPassphrase)
+		if err != nil {
+			return FieldError{Key: "SECRET_PASSPHRASE", Message: err.Error()}
+		}
+		e.SecretPassphrase = value
+	}
+	return nil
+}
+
+// ResolveSecrets replaces all references (keyring:, file: and cmd:) in string values,
+// errors are returned per variable
+func (e *EnvConfig) ResolveSecrets() error {
+	if err := e.ResolvePassphrase(); err != nil {
+		return err
+	}
+
+	var store secrets.Store
+	var errs []error
+	v := reflect.ValueOf(e).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("env")
+		field := v.Field(i)
+		if key == "" || field.Kind() != reflect.String || !IsSecretRef(field.String()) {
+			continue
+		}
+		if store == nil && strings.HasPrefix(field.String(), RefKeyring) {
+			var err error
+			if store, err = e.OpenSecretStore(); err != nil {
+				return FieldError{Key: "SECRET_STORE", Message: err.Error()}
+			}
+		}
+		value, err := e.resolve(store, field.String())
+		if err != nil {
+			errs = append(errs, FieldError{Key: key, Message: err.Error()})
+			continue
+		}
+		field.SetString(value)
+	}
+	return errors.Join(errs...)
+}
+
+// Resolves a single reference
+func (e *EnvConfig) resolve(store secrets.Store, ref string) (string, error) {
+	if name, ok := strings.CutPrefix(ref, RefKeyring); ok {
+		return store.Get(name)
+	}
+	if path, ok := strings.CutPrefix(ref, RefFile); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	command, _ := strings.CutPrefix(ref, RefCommand)
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	// No stdin, it may carry piped input (e.g. of "upload -") which must not be drained
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// Never include the output, it may contain (parts of) the secret
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("secret command %q failed: %w (%s)", command, err, msg)
+		}
+		return "", fmt.Errorf("secret command %q failed: %w", command, err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+func (e *EnvConfig) passphrase(create bool) (string, error) {
+	if e.SecretPassphrase != "" {
+		return e.SecretPassphrase, nil
+	}
+	if PassphrasePrompt == nil {
+		return "", fmt.Errorf("LINK_SECRET_PASSPHRASE is required for the encrypted secret file")
+	}
+	passphrase, err := PassphrasePrompt(create)
+	if err != nil {
+		return "", err
+	}
+	// Asked only once per run
+	e.SecretPassphrase = passphrase
+	return passphrase, nil
+}
+
+const (
+	commandTimeout time.Duration = 30 * time.Second
+)
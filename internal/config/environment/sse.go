@@ -0,0 +1,69 @@
+package environment
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SSE describes the server side encryption of uploads
+type SSE struct {
+	// SSES3, SSEKMS or SSEC, empty if uploads are not encrypted
+	Type string
+	// Key id for SSE-KMS, empty to use the server's default key
+	KeyID string
+	// File containing the 32 byte key for SSE-C
+	KeyFile string
+}
+
+// ParseSSE parses "s3", "kms[:<key-id>]" or "c:<keyfile>",
+// an empty value or "none" disables server side encryption
+func ParseSSE(value string) (SSE, error) {
+	kind, arg, _ := strings.Cut(strings.TrimSpace(value), ":")
+	switch strings.ToLower(kind) {
+	case "", "none":
+		if arg != "" {
+			break
+		}
+		return SSE{}, nil
+	case SSES3:
+		if arg != "" {
+			break
+		}
+		return SSE{Type: SSES3}, nil
+	case SSEKMS:
+		return SSE{Type: SSEKMS, KeyID: arg}, nil
+	case SSEC:
+		if arg == "" {
+			return SSE{}, fmt.Errorf("sse %q requires a key file (c:<keyfile>)", value)
+		}
+		return SSE{Type: SSEC, KeyFile: arg}, nil
+	}
+	return SSE{}, fmt.Errorf(
+		"unsupported sse %q (supported: none, s3, kms[:<key-id>], c:<keyfile>)",
+		value,
+	)
+}
+
+// String formats the encryption like it is accepted by ParseSSE,
+// empty if uploads are not encrypted
+func (s SSE) String() string {
+	switch s.Type {
+	case SSEKMS:
+		if s.KeyID == "" {
+			return SSEKMS
+		}
+		return SSEKMS + ":" + s.KeyID
+	case SSEC:
+		return SSEC + ":" + s.KeyFile
+	}
+	return s.Type
+}
+
+const (
+	// SSES3 encrypts objects with keys managed by the server
+	SSES3 string = "s3"
+	// SSEKMS encrypts objects with a key of the server's KMS
+	SSEKMS string = "kms"
+	// SSEC encrypts objects with a key sent along with every request
+	SSEC string = "c"
+)
@@ -0,0 +1,49 @@
+package environment
+
+import "testing"
+
+func TestParseSSE(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    SSE
+		wantErr bool
+	}{
+		{input: "", want: SSE{}},
+		{input: "none", want: SSE{}},
+		{input: " NONE ", want: SSE{}},
+		{input: "s3", want: SSE{Type: SSES3}},
+		{input: "S3", want: SSE{Type: SSES3}},
+		{input: "kms", want: SSE{Type: SSEKMS}},
+		{input: "kms:my-key", want: SSE{Type: SSEKMS, KeyID: "my-key"}},
+		{input: "c:/etc/sse.key", want: SSE{Type: SSEC, KeyFile: "/etc/sse.key"}},
+		// Only the first colon separates the key file, Windows paths keep theirs
+		{input: `c:C:\keys\sse.key`, want: SSE{Type: SSEC, KeyFile: `C:\keys\sse.key`}},
+		{input: "c", wantErr: true},
+		{input: "c:", wantErr: true},
+		{input: "none:key", wantErr: true},
+		{input: "s3:key", wantErr: true},
+		{input: "aes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSSE(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			// String has to be accepted by ParseSSE again
+			again, err := ParseSSE(got.String())
+			if err != nil || again != got {
+				t.Fatalf("String %q parsed to %+v (%v), want %+v", got.String(), again, err, got)
+			}
+		})
+	}
+}
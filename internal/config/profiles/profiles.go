@@ -0,0 +1,176 @@
+package profiles
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/pkg/system"
+)
+
+// Profile holds config values keyed by variable name without the LINK_ prefix
+// (e.g. "minio_endpoint"), unset values fall back to the defaults
+type Profile map[string]string
+
+// File is the config file holding all named profiles
+type File struct {
+	// Profile used if neither --profile nor LINK_PROFILE is set
+	DefaultProfile string             `yaml:"default_profile,omitempty"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+
+	path string
+}
+
+// Path returns the path of the underlying config file
+func (f *File) Path() string {
+	return f.path
+}
+
+// Names returns all profile names sorted alphabetically
+func (f *File) Names() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Get returns the profile with the given name
+func (f *File) Get(name string) (Profile, error) {
+	profile, ok := f.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in %s", name, f.path)
+	}
+	return profile, nil
+}
+
+// Add creates a profile or merges the values into an existing one,
+// empty values remove the key from the profile
+func (f *File) Add(name string, values map[string]string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if f.Profiles == nil {
+		f.Profiles = make(map[string]Profile)
+	}
+	profile, ok := f.Profiles[name]
+	if !ok {
+		profile = make(Profile)
+	}
+	for key, value := range values {
+		normalized, err := environment.NormalizeKey(key)
+		if err != nil {
+			return err
+		}
+		normalized = strings.ToLower(normalized)
+		if value == "" {
+			delete(profile, normalized)
+			continue
+		}
+		profile[normalized] = value
+	}
+	f.Profiles[name] = profile
+	return nil
+}
+
+// Remove deletes a profile (and resets the default profile if it was the default)
+func (f *File) Remove(name string) error {
+	if _, ok := f.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found in %s", name, f.path)
+	}
+	delete(f.Profiles, name)
+	if f.DefaultProfile == name {
+		f.DefaultProfile = ""
+	}
+	return nil
+}
+
+// SetDefault sets the profile used if neither --profile nor LINK_PROFILE is set
+func (f *File) SetDefault(name string) error {
+	if _, ok := f.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found in %s", name, f.path)
+	}
+	f.DefaultProfile = name
+	return nil
+}
+
+// Save writes the config file, it is only readable by the current user
+// because profiles usually contain credentials
+func (f *File) Save() error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return fmt.Errorf("failed to marshal profiles: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write profiles: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("failed to write profiles: %w", err)
+	}
+	return nil
+}
+
+// Load reads the config file, a missing file results in an empty one,
+// if path = "" the default file in the user's config directory will be used
+func Load(path string) (*File, error) {
+	if path == "" {
+		var err error
+		path, err = DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+	f := &File{Profiles: make(map[string]Profile), path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles: %w", err)
+	}
+	if f.Profiles == nil {
+		f.Profiles = make(map[string]Profile)
+	}
+	return f, nil
+}
+
+// DefaultPath returns the path of the config file in the user's config directory
+func DefaultPath() (string, error) {
+	dir, err := system.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, defaultDirectory, defaultFileName), nil
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("profile name must not be empty")
+	}
+	if strings.ContainsAny(name, " \t\n:/\\") {
+		return fmt.Errorf("invalid profile name: %q", name)
+	}
+	return nil
+}
+
+const (
+	defaultDirectory string = "minio-link"
+	defaultFileName  string = "config.yaml"
+)
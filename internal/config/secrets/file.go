@@ -0,0 +1,197 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// PassphraseFunc returns the passphrase of the encrypted file store,
+// create is set if the file does not exist yet (prompts should ask twice)
+type PassphraseFunc func(create bool) (string, error)
+
+// FileStore keeps secrets in a file encrypted with AES-256-GCM,
+// the key is derived from a passphrase via Argon2id
+type FileStore struct {
+	path       string
+	passphrase PassphraseFunc
+
+	mu sync.Mutex
+	// Cached after the first successful read so the passphrase is only asked once
+	key     []byte
+	salt    []byte
+	secrets map[string]string
+}
+
+func (s *FileStore) Name() string {
+	return StoreFile
+}
+
+// Path returns the path of the encrypted file
+func (s *FileStore) Path() string {
+	return s.path
+}
+
+func (s *FileStore) Get(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return "", err
+	}
+	value, ok := s.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w in %s: %s", ErrNotFound, s.path, name)
+	}
+	return value, nil
+}
+
+func (s *FileStore) Set(name string, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.secrets[name] = value
+	return s.save()
+}
+
+func (s *FileStore) exists() bool {
+	_, err := os.Stat(s.path)
+	return err == nil
+}
+
+// Decrypts the file (if it exists), a new file gets a random salt
+func (s *FileStore) load() error {
+	if s.secrets != nil {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.salt = make([]byte, saltSize)
+		if _, err := rand.Read(s.salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+		if s.key, err = s.deriveKey(s.salt, true); err != nil {
+			return err
+		}
+		s.secrets = make(map[string]string)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	var f encryptedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("failed to parse secret file: %w", err)
+	}
+	if f.Version != fileVersion {
+		return fmt.Errorf("unsupported secret file version: %d", f.Version)
+	}
+	key, err := s.deriveKey(f.Salt, false)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret file (wrong passphrase?)")
+	}
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("failed to parse secrets: %w", err)
+	}
+	s.key, s.salt, s.secrets = key, f.Salt, secrets
+	return nil
+}
+
+// Encrypts all secrets with a fresh nonce and replaces the file
+func (s *FileStore) save() error {
+	plain, err := json.Marshal(s.secrets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	data, err := json.Marshal(encryptedFile{
+		Version: fileVersion,
+		Salt:    s.salt,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plain, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal secret file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create secret directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write secret file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write secret file: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) deriveKey(salt []byte, create bool) ([]byte, error) {
+	if s.passphrase == nil {
+		return nil, fmt.Errorf("no passphrase for secret file %s", s.path)
+	}
+	passphrase, err := s.passphrase(create)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passphrase: %w", err)
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase for secret file must not be empty")
+	}
+	return argon2.IDKey([]byte(passphrase), salt, argonTime, argonMemory, argonThreads, 32), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+// On disk format, byte slices are base64 encoded by encoding/json
+type encryptedFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+const (
+	fileVersion int = 1
+	saltSize    int = 16
+
+	// Argon2id parameters recommended by RFC 9106 for memory constrained environments
+	argonTime    uint32 = 3
+	argonMemory  uint32 = 64 * 1024
+	argonThreads uint8  = 4
+)
@@ -0,0 +1,167 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	var creates []bool
+	store := newTestStore(t, path, func(create bool) (string, error) {
+		creates = append(creates, create)
+		return "correct horse", nil
+	})
+	if err := store.Set("token", "s3cr3t"); err != nil {
+		t.Fatalf("failed to set secret: %v", err)
+	}
+	if err := store.Set("other", "value"); err != nil {
+		t.Fatalf("failed to set secret: %v", err)
+	}
+
+	reopened := newTestStore(t, path, func(create bool) (string, error) {
+		creates = append(creates, create)
+		return "correct horse", nil
+	})
+	got, err := reopened.Get("token")
+	if err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	if got != "s3cr3t" {
+		t.Fatalf("got %q, want %q", got, "s3cr3t")
+	}
+	if _, err := reopened.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got error %v, want %v", err, ErrNotFound)
+	}
+	// Asked once per store, only the first time for a new file
+	if len(creates) != 2 || !creates[0] || creates[1] {
+		t.Fatalf("passphrase asked with create %v, want [true false]", creates)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cr3t") {
+		t.Fatal("secret file contains the plain secret")
+	}
+}
+
+func TestFileStoreFails(t *testing.T) {
+	tests := []struct {
+		name       string
+		passphrase string
+		// Changes the valid file written with the passphrase "correct horse"
+		modify  func(t *testing.T, f *encryptedFile) []byte
+		wantErr string
+	}{
+		{
+			name:       "wrong passphrase",
+			passphrase: "wrong horse",
+			wantErr:    "wrong passphrase",
+		},
+		{
+			name:       "empty passphrase",
+			passphrase: "",
+			wantErr:    "must not be empty",
+		},
+		{
+			name:       "modified data",
+			passphrase: "correct horse",
+			modify: func(t *testing.T, f *encryptedFile) []byte {
+				f.Data[0] ^= 0xff
+				return marshalFile(t, f)
+			},
+			wantErr: "failed to decrypt",
+		},
+		{
+			name:       "truncated data",
+			passphrase: "correct horse",
+			modify: func(t *testing.T, f *encryptedFile) []byte {
+				f.Data = f.Data[:len(f.Data)-1]
+				return marshalFile(t, f)
+			},
+			wantErr: "failed to decrypt",
+		},
+		{
+			name:       "modified salt",
+			passphrase: "correct horse",
+			modify: func(t *testing.T, f *encryptedFile) []byte {
+				f.Salt[0] ^= 0xff
+				return marshalFile(t, f)
+			},
+			wantErr: "failed to decrypt",
+		},
+		{
+			name:       "unsupported version",
+			passphrase: "correct horse",
+			modify: func(t *testing.T, f *encryptedFile) []byte {
+				f.Version = fileVersion + 1
+				return marshalFile(t, f)
+			},
+			wantErr: "unsupported secret file version",
+		},
+		{
+			name:       "no json",
+			passphrase: "correct horse",
+			modify: func(t *testing.T, f *encryptedFile) []byte {
+				return []byte("not json")
+			},
+			wantErr: "failed to parse secret file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "secrets.enc")
+			store := newTestStore(t, path, func(bool) (string, error) {
+				return "correct horse", nil
+			})
+			if err := store.Set("token", "s3cr3t"); err != nil {
+				t.Fatalf("failed to set secret: %v", err)
+			}
+			if tt.modify != nil {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var f encryptedFile
+				if err := json.Unmarshal(data, &f); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, tt.modify(t, &f), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			reopened := newTestStore(t, path, func(bool) (string, error) {
+				return tt.passphrase, nil
+			})
+			_, err := reopened.Get("token")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func newTestStore(t *testing.T, path string, passphrase PassphraseFunc) Store {
+	t.Helper()
+	store, err := New(StoreFile, path, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func marshalFile(t *testing.T, f *encryptedFile) []byte {
+	t.Helper()
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,136 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+
+	"github.com/devusSs/minio-link/pkg/system"
+)
+
+// Store holds named secrets
+type Store interface {
+	// Name returns the name of the store shown to users
+	Name() string
+	// Get returns ErrNotFound if there is no secret with the given name
+	Get(name string) (string, error)
+	Set(name string, value string) error
+}
+
+// ErrNotFound is returned if a secret does not exist
+var ErrNotFound = errors.New("secret not found")
+
+// Stores which can be chosen via config
+const (
+	// Uses the OS keyring and falls back to the encrypted file if there is none
+	StoreAuto    string = "auto"
+	StoreKeyring string = "keyring"
+	StoreFile    string = "file"
+)
+
+// New creates the store with the given name, path and passphrase are only
+// used by the encrypted file store (default path if empty)
+func New(store string, path string, passphrase PassphraseFunc) (Store, error) {
+	if path == "" {
+		var err error
+		path, err = DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+	file := &FileStore{path: path, passphrase: passphrase}
+	switch strings.ToLower(store) {
+	case StoreAuto:
+		return &autoStore{keyring: &KeyringStore{}, file: file}, nil
+	case StoreKeyring:
+		return &KeyringStore{}, nil
+	case StoreFile:
+		return file, nil
+	}
+	return nil, fmt.Errorf("unsupported secret store: %s (supported: auto, keyring, file)", store)
+}
+
+// DefaultPath returns the path of the encrypted file in the user's config directory
+func DefaultPath() (string, error) {
+	dir, err := system.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, defaultDirectory, defaultFileName), nil
+}
+
+// KeyringStore uses the OS keyring (Keychain, Secret Service or Credential Manager)
+type KeyringStore struct{}
+
+func (s *KeyringStore) Name() string {
+	return StoreKeyring
+}
+
+func (s *KeyringStore) Get(name string) (string, error) {
+	value, err := keyring.Get(keyringService, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", fmt.Errorf("%w in keyring: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read keyring: %w", err)
+	}
+	return value, nil
+}
+
+func (s *KeyringStore) Set(name string, value string) error {
+	if err := keyring.Set(keyringService, name, value); err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	return nil
+}
+
+// Uses the keyring if available, else the encrypted file (e.g. headless Linux
+// without Secret Service)
+type autoStore struct {
+	keyring *KeyringStore
+	file    *FileStore
+	// Set once the keyring failed, avoids trying it again for every secret
+	noKeyring bool
+}
+
+func (s *autoStore) Name() string {
+	if s.noKeyring {
+		return s.file.Name()
+	}
+	return s.keyring.Name()
+}
+
+func (s *autoStore) Get(name string) (string, error) {
+	if !s.noKeyring {
+		value, err := s.keyring.Get(name)
+		if err == nil {
+			return value, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			s.noKeyring = true
+		}
+	}
+	if !s.file.exists() {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return s.file.Get(name)
+}
+
+func (s *autoStore) Set(name string, value string) error {
+	if !s.noKeyring {
+		if err := s.keyring.Set(name, value); err == nil {
+			return nil
+		}
+		s.noKeyring = true
+	}
+	return s.file.Set(name, value)
+}
+
+const (
+	keyringService   string = "minio-link"
+	defaultDirectory string = "minio-link"
+	defaultFileName  string = "secrets.enc"
+)
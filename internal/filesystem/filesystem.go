@@ -0,0 +1,132 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/log"
+)
+
+// Stores uploads in a local directory which is served by a web server (e.g. nginx)
+type blobStore struct {
+	root string
+}
+
+func (b *blobStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	p, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp := p + ".part"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	_, err = io.Copy(f, contextReader{ctx: ctx, r: r})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return fmt.Errorf("failed to move file into place: %w", err)
+	}
+	return nil
+}
+
+func (b *blobStore) Get(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	p, err := b.path(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, 0, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, stat.Size(), nil
+}
+
+func (b *blobStore) Stat(ctx context.Context, key string) (storage.BlobInfo, error) {
+	p, err := b.path(key)
+	if err != nil {
+		return storage.BlobInfo{}, err
+	}
+	stat, err := os.Stat(p)
+	if err != nil {
+		return storage.BlobInfo{}, err
+	}
+	return storage.BlobInfo{Size: stat.Size(), LastModified: stat.ModTime()}, nil
+}
+
+func (b *blobStore) Remove(ctx context.Context, key string) error {
+	p, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+// Maps a key to a path below root, keys escaping root are rejected
+func (b *blobStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "\x00") {
+		return "", fmt.Errorf("invalid object key: %s", key)
+	}
+	return filepath.Join(b.root, filepath.FromSlash(clean)), nil
+}
+
+// Stops long copies once the context is cancelled
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// NewClient creates a new object store using a local directory
+func NewClient(dir string, debug bool, cfg *environment.EnvConfig) (*storage.WebStore, error) {
+	if cfg.FilesystemDirectory == "" {
+		return nil, fmt.Errorf("LINK_FS_DIRECTORY is required for the filesystem storage")
+	}
+	if cfg.FilesystemBaseURL == "" {
+		return nil, fmt.Errorf("LINK_FS_BASE_URL is required for the filesystem storage")
+	}
+	root, err := filepath.Abs(cfg.FilesystemDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute directory: %w", err)
+	}
+	logger := log.NewLogger().
+		WithDirectory(dir).
+		WithName("filesystem").
+		WithDebug(debug).
+		WithConsoleOutput(debug)
+	return storage.NewWebStore(
+		logger,
+		&blobStore{root: root},
+		storage.WebLinks{BaseURL: cfg.FilesystemBaseURL, Secret: cfg.FilesystemLinkSecret},
+		cfg.MinioBucketName,
+		cfg.MinioDefaultExpiry,
+	), nil
+}
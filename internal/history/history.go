@@ -0,0 +1,284 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devusSs/minio-link/pkg/system"
+)
+
+// Entry is a single upload recorded in the history
+type Entry struct {
+	Time        time.Time  `json:"time" yaml:"time"`
+	FileName    string     `json:"file_name" yaml:"file_name"`
+	Object      string     `json:"object" yaml:"object"`
+	Bucket      string     `json:"bucket" yaml:"bucket"`
+	Public      bool       `json:"public" yaml:"public"`
+	Size        int64      `json:"size" yaml:"size"`
+	ContentType string     `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+	Checksum    string     `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	Encryption  string     `json:"encryption,omitempty" yaml:"encryption,omitempty"`
+	Expires     *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+	DeleteAt    *time.Time `json:"delete_at,omitempty" yaml:"delete_at,omitempty"`
+	MinioURL    string     `json:"minio_url" yaml:"minio_url"`
+	ShortURL    string     `json:"short_url,omitempty" yaml:"short_url,omitempty"`
+}
+
+// Link returns the short url if there is one, else the minio url
+func (e *Entry) Link() string {
+	if e.ShortURL != "" {
+		return e.ShortURL
+	}
+	return e.MinioURL
+}
+
+// Filter narrows down the entries returned by Store.List
+type Filter struct {
+	// Case insensitive search in file name, object, bucket and urls
+	Search string
+	Since  time.Time
+	Until  time.Time
+	// Maximum amount of (newest) entries, 0 means no limit
+	Limit int
+}
+
+func (f *Filter) matches(e *Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	if f.Search == "" {
+		return true
+	}
+	search := strings.ToLower(f.Search)
+	for _, field := range []string{e.FileName, e.Object, e.Bucket, e.MinioURL, e.ShortURL} {
+		if strings.Contains(strings.ToLower(field), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// Store is an append only JSON lines file holding the upload history, it is shared
+// by every process of the user (e.g. serve, watch and single commands)
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Path returns the path of the underlying history file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Add appends an entry to the history
+func (s *Store) Add(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// List returns all entries matching the filter, newest first
+func (s *Store) List(filter Filter) ([]Entry, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	all, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(all))
+	for _, e := range all {
+		if filter.matches(&e) {
+			entries = append(entries, e)
+		}
+	}
+	slices.Reverse(entries)
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, nil
+}
+
+// Update calls update for every entry matching the given bucket and object
+// and rewrites the history, returns the amount of updated entries
+func (s *Store) Update(bucket string, object string, update func(e *Entry)) (int, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	entries, err := s.read()
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	for i := range entries {
+		if entries[i].Bucket == bucket && entries[i].Object == object {
+			update(&entries[i])
+			updated++
+		}
+	}
+	if updated == 0 {
+		return 0, nil
+	}
+	return updated, s.write(entries)
+}
+
+// Remove removes all entries matching the given bucket and object,
+// returns the amount of removed entries
+func (s *Store) Remove(bucket string, object string) (int, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	entries, err := s.read()
+	if err != nil {
+		return 0, err
+	}
+	kept := slices.DeleteFunc(slices.Clone(entries), func(e Entry) bool {
+		return e.Bucket == bucket && e.Object == object
+	})
+	removed := len(entries) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.write(kept)
+}
+
+// Reads all entries in insertion order
+func (s *Store) read() ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Skip broken lines (e.g. partial writes) instead of losing the whole history
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+// Replaces the history file with the given entries
+func (s *Store) write(entries []Entry) error {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	// Written next to the history so the rename replaces it atomically
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary history: %w", err)
+	}
+	_, err = f.Write(buf.Bytes())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Locks the history against other goroutines and processes until the returned
+// function is called, the history file itself can not be locked as write replaces it
+func (s *Store) lock() (func(), error) {
+	s.mu.Lock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path+lockSuffix, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to open history lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to lock history: %w", err)
+	}
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+		s.mu.Unlock()
+	}, nil
+}
+
+// NewStore creates a store using the given file,
+// if path = "" the default file in the user's data directory will be used
+func NewStore(path string) (*Store, error) {
+	if path == "" {
+		dir, err := system.GetDataDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, defaultDirectory, defaultFileName)
+	}
+	return &Store{path: path}, nil
+}
+
+const (
+	defaultDirectory string = "minio-link"
+	defaultFileName  string = "history.jsonl"
+	lockSuffix       string = ".lock"
+)
@@ -0,0 +1,311 @@
+package kutt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/pkg/log"
+)
+
+// Wrapper for Kutt API (v2)
+type KuttClient struct {
+	logger  *log.Logger
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// ShortenURL shortens a URL via Kutt
+func (c *KuttClient) ShortenURL(ctx context.Context, input string) (string, error) {
+	if _, err := url.ParseRequestURI(input); err != nil {
+		return "", fmt.Errorf("invalid input url: %w", err)
+	}
+
+	body := createLinkRequest{Target: input, Description: defaultUploadTitle}
+	var res link
+	if err := c.do(ctx, http.MethodPost, "/links", nil, body, &res); err != nil {
+		return "", fmt.Errorf("failed to shorten url: %w", err)
+	}
+
+	c.logger.Debug(fmt.Sprintf("shortened url: %s", res.Link))
+
+	return res.Link, nil
+}
+
+// ExpandURL expands a shortened URL via Kutt
+func (c *KuttClient) ExpandURL(ctx context.Context, input string) (string, error) {
+	l, err := c.findLink(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand url: %w", err)
+	}
+
+	c.logger.Debug(fmt.Sprintf("expanded url: %s", l.Target))
+
+	return l.Target, nil
+}
+
+// UpdateURL points an existing shortened URL to a new long URL via Kutt
+func (c *KuttClient) UpdateURL(ctx context.Context, shortURL string, input string) error {
+	if _, err := url.ParseRequestURI(input); err != nil {
+		return fmt.Errorf("invalid input url: %w", err)
+	}
+	l, err := c.findLink(ctx, shortURL)
+	if err != nil {
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+
+	body := editLinkRequest{Target: input, Address: l.Address, Description: l.Description}
+	if err := c.do(ctx, http.MethodPatch, "/links/"+l.ID, nil, body, nil); err != nil {
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+
+	c.logger.Debug(fmt.Sprintf("updated url: %s -> %s", shortURL, input))
+
+	return nil
+}
+
+// DeleteURL deletes a shortened URL via Kutt
+func (c *KuttClient) DeleteURL(ctx context.Context, shortURL string) error {
+	l, err := c.findLink(ctx, shortURL)
+	if err != nil {
+		return fmt.Errorf("failed to delete url: %w", err)
+	}
+
+	if err := c.do(ctx, http.MethodDelete, "/links/"+l.ID, nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete url: %w", err)
+	}
+
+	c.logger.Debug(fmt.Sprintf("deleted url: %s", shortURL))
+
+	return nil
+}
+
+// ListURLs gets the shortened urls of this program, newest first
+func (c *KuttClient) ListURLs(ctx context.Context, limit int) ([]shortener.Link, error) {
+	if limit < 1 {
+		limit = shortener.DefaultLinkLimit
+		c.logger.Debug(
+			fmt.Sprintf("limit is less than 1, set to default limit: %d", limit),
+		)
+	}
+
+	var urls []shortener.Link
+	err := c.eachLink(ctx, "", func(l *link) bool {
+		if strings.Contains(l.Description, defaultUploadTitle) {
+			urls = append(urls, l.toLink())
+		}
+		return len(urls) < limit
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list urls: %w", err)
+	}
+
+	c.logger.Debug(fmt.Sprintf("found %d urls", len(urls)))
+
+	return urls, nil
+}
+
+// Stats gets details and visits of a single shortened URL via Kutt
+func (c *KuttClient) Stats(ctx context.Context, shortURL string) (*shortener.Link, error) {
+	l, err := c.findLink(ctx, shortURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get url stats: %w", err)
+	}
+	res := l.toLink()
+	return &res, nil
+}
+
+// CheckConnection checks endpoint and api key by listing a single url
+func (c *KuttClient) CheckConnection(ctx context.Context) (string, error) {
+	if _, err := c.ListURLs(ctx, 1); err != nil {
+		return "", err
+	}
+	return "api key valid", nil
+}
+
+// NewClient creates a new KuttClient
+func NewClient(dir string, debug bool, cfg *environment.EnvConfig) *KuttClient {
+	return &KuttClient{
+		logger: log.NewLogger().
+			WithDirectory(dir).
+			WithName("kutt").
+			WithDebug(debug).
+			WithConsoleOutput(debug),
+		client:  &http.Client{Timeout: 5 * time.Second},
+		baseURL: strings.TrimSuffix(cfg.KuttEndpoint, "/"),
+		apiKey:  cfg.KuttAPIKey,
+	}
+}
+
+// Kutt has no lookup by short url, so we search our links for the matching address
+func (c *KuttClient) findLink(ctx context.Context, shortURL string) (*link, error) {
+	u, err := url.Parse(shortURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid short url: %w", err)
+	}
+	address := strings.Trim(u.Path, "/")
+	if address == "" {
+		return nil, fmt.Errorf("invalid short url: %s", shortURL)
+	}
+	var found *link
+	err = c.eachLink(ctx, address, func(l *link) bool {
+		if l.Link == shortURL || l.Address == address {
+			found = l
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("short url not found: %s", shortURL)
+	}
+	return found, nil
+}
+
+// Pages through the links of the account (newest first) until fn returns false
+func (c *KuttClient) eachLink(ctx context.Context, search string, fn func(l *link) bool) error {
+	for skip := 0; ; skip += pageSize {
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(pageSize))
+		query.Set("skip", strconv.Itoa(skip))
+		if search != "" {
+			query.Set("search", search)
+		}
+		var res listLinksResponse
+		if err := c.do(ctx, http.MethodGet, "/links", query, nil, &res); err != nil {
+			return err
+		}
+		for i := range res.Data {
+			if !fn(&res.Data[i]) {
+				return nil
+			}
+		}
+		if len(res.Data) < pageSize || skip+len(res.Data) >= res.Total {
+			return nil
+		}
+	}
+}
+
+// Sends a JSON request to the api and decodes the JSON response into v if v != nil
+func (c *KuttClient) do(
+	ctx context.Context,
+	method string,
+	path string,
+	query url.Values,
+	body any,
+	v any,
+) error {
+	u, err := url.Parse(c.baseURL + defaultAPIPath + path)
+	if err != nil {
+		return fmt.Errorf("invalid base url: %w", err)
+	}
+	u.RawQuery = query.Encode()
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Add("X-API-KEY", c.apiKey)
+	req.Header.Add("Accept", "application/json")
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	c.logger.Debug(fmt.Sprintf("url: %s, method: %s", req.URL.String(), req.Method))
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	c.logger.Debug(fmt.Sprintf("response: %s (%d)", res.Status, res.StatusCode))
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var errRes errorResponse
+		if err := json.Unmarshal(data, &errRes); err != nil || errRes.Error == "" {
+			return fmt.Errorf("unexpected response: %s", res.Status)
+		}
+		return fmt.Errorf("%s", errRes.Error)
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return nil
+}
+
+const (
+	defaultAPIPath     string = "/api/v2"
+	defaultUploadTitle string = "Uploaded using minio-yourls-uploader by devusSs"
+	pageSize           int    = 50
+)
+
+type createLinkRequest struct {
+	Target      string `json:"target"`
+	Description string `json:"description"`
+}
+
+type editLinkRequest struct {
+	Target      string `json:"target"`
+	Address     string `json:"address"`
+	Description string `json:"description,omitempty"`
+}
+
+type link struct {
+	ID          string    `json:"id"`
+	Address     string    `json:"address"`
+	Link        string    `json:"link"`
+	Target      string    `json:"target"`
+	Description string    `json:"description"`
+	VisitCount  int       `json:"visit_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (l *link) toLink() shortener.Link {
+	return shortener.Link{
+		ShortURL: l.Link,
+		URL:      l.Target,
+		Created:  l.CreatedAt,
+		Clicks:   l.VisitCount,
+	}
+}
+
+type listLinksResponse struct {
+	Limit int    `json:"limit"`
+	Skip  int    `json:"skip"`
+	Total int    `json:"total"`
+	Data  []link `json:"data"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,67 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Entry is a single uploaded file listed in the manifest
+type Entry struct {
+	Name string
+	Size int64
+	URL  string
+}
+
+// Render renders an HTML index page listing all entries with their links
+func Render(title string, entries []Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	err := indexTemplate.Execute(&buf, struct {
+		Title   string
+		Created string
+		Entries []Entry
+	}{
+		Title:   title,
+		Created: time.Now().Format(time.RFC1123),
+		Entries: entries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render manifest: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+const (
+	// FileName is the object name of the manifest inside an upload prefix
+	FileName    string = "index.html"
+	ContentType string = "text/html; charset=utf-8"
+)
+
+var indexTemplate = template.Must(template.New("index").Funcs(template.FuncMap{
+	"size": func(size int64) string { return humanize.IBytes(uint64(size)) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+td, th { padding: 0.25em 1em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<p>{{ len .Entries }} file(s), created {{ .Created }}</p>
+<table>
+<tr><th>File</th><th>Size</th></tr>
+{{- range .Entries }}
+<tr><td><a href="{{ .URL }}">{{ .Name }}</a></td><td>{{ size .Size }}</td></tr>
+{{- end }}
+</table>
+<p><small>Uploaded using minio-link</small></p>
+</body>
+</html>
+`))
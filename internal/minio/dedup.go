@@ -0,0 +1,114 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	miniolib "github.com/minio/minio-go/v7"
+
+	"github.com/devusSs/minio-link/internal/storage"
+)
+
+// Looks up the object with the same content and server side encryption as the file
+// in the checksum index (see indexObject), the object is checked again before reuse,
+// returns nil if there is none
+func (c *MinioClient) findObject(
+	ctx context.Context,
+	bucketName string,
+	filePath string,
+	checksum string,
+) (*miniolib.ObjectInfo, error) {
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	indexBucket := c.bucket(false)
+	indexKey := checksumIndexKey(bucketName, checksum)
+	entry, err := c.client.StatObject(ctx, indexBucket, indexKey, miniolib.StatObjectOptions{})
+	if err != nil {
+		if miniolib.ToErrorResponse(err).Code == "NoSuchKey" ||
+			miniolib.ToErrorResponse(err).Code == "NoSuchBucket" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up checksum index: %w", err)
+	}
+	objectName := entry.UserMetadata[indexObjectHeader]
+	// Files uploaded via requested links are written by others
+	if objectName == "" || strings.HasPrefix(objectName, storage.InboxPrefix) {
+		return nil, nil
+	}
+
+	info, _, err := c.statObject(ctx, bucketName, objectName)
+	if err != nil {
+		c.logger.Debug(fmt.Sprintf("failed to stat %s: %s", objectName, err))
+		if miniolib.ToErrorResponse(err).Code == "NoSuchKey" {
+			c.removeIndex(ctx, indexBucket, indexKey)
+		}
+		return nil, nil
+	}
+	// The object may have been replaced since it was indexed
+	if info.Size != stat.Size() || info.UserMetadata[checksumMetaHeader] != checksum {
+		c.removeIndex(ctx, indexBucket, indexKey)
+		return nil, nil
+	}
+	// Reusing it would leave the content encrypted differently than requested
+	if info.UserMetadata[sseMetaHeader] != c.sseConfig.Type {
+		return nil, nil
+	}
+	// Would be deleted before the new upload is expected to be
+	if _, ok := objectExpiry(info); ok {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+// Records the object as the upload of the checksum, the index lives in the private
+// bucket so public buckets do not tell which object holds a known file, nothing is
+// recorded as long as the private bucket does not exist (it is never created for this)
+func (c *MinioClient) indexObject(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+	checksum string,
+) error {
+	_, err := c.client.PutObject(
+		ctx,
+		c.bucket(false),
+		checksumIndexKey(bucketName, checksum),
+		bytes.NewReader(nil),
+		0,
+		miniolib.PutObjectOptions{UserMetadata: map[string]string{indexObjectKey: objectName}},
+	)
+	if miniolib.ToErrorResponse(err).Code == "NoSuchBucket" {
+		c.logger.Debug(fmt.Sprintf("no private bucket, not indexing %s", objectName))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update checksum index: %w", err)
+	}
+	return nil
+}
+
+// Drops index entries of objects which are gone or changed
+func (c *MinioClient) removeIndex(ctx context.Context, bucketName string, indexKey string) {
+	err := c.client.RemoveObject(ctx, bucketName, indexKey, miniolib.RemoveObjectOptions{})
+	if err != nil {
+		c.logger.Debug(fmt.Sprintf("failed to remove stale index %s: %s", indexKey, err))
+	}
+}
+
+func checksumIndexKey(bucketName string, checksum string) string {
+	return path.Join(checksumIndexPrefix, bucketName, checksum)
+}
+
+const (
+	checksumMetaKey     string = "minio-link-sha256"
+	checksumMetaHeader  string = "Minio-Link-Sha256"
+	checksumIndexPrefix string = ".minio-link/sha256"
+	indexObjectKey      string = "minio-link-object"
+	indexObjectHeader   string = "Minio-Link-Object"
+)
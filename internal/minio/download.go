@@ -0,0 +1,435 @@
+package minio
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	miniolib "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/progress"
+)
+
+// Downloads the object in concurrent byte ranges into path + ".part", finished ranges
+// are recorded in a sidecar state file so an interrupted download can be continued,
+// the file is only moved into place after its ETag and checksum were verified
+func (c *MinioClient) getObjectToFile(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+	path string,
+) (*storage.Download, error) {
+	stat, key, err := c.statObject(ctx, bucketName, objectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+	tmpPath := path + ".part"
+	state, err := readDownloadState(tmpPath + downloadStateSuffix)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if state != nil && !state.matches(bucketName, stat, tmpPath) {
+		c.logger.Debug(fmt.Sprintf("discarding outdated partial download of %s", objectName))
+		state = nil
+	}
+	flags := os.O_RDWR | os.O_CREATE
+	if state == nil {
+		state = &downloadState{
+			Bucket:    bucketName,
+			Object:    objectName,
+			ETag:      stat.ETag,
+			Size:      stat.Size,
+			ChunkSize: downloadChunkSize,
+			Started:   time.Now(),
+			path:      tmpPath + downloadStateSuffix,
+		}
+		flags |= os.O_TRUNC
+	} else {
+		c.logger.Debug(fmt.Sprintf(
+			"resuming download of %s with %d/%d finished chunks",
+			objectName,
+			len(state.Chunks),
+			state.chunkCount(),
+		))
+	}
+	partSize, verifiable := c.etagPartSize(ctx, bucketName, objectName, stat, key)
+	f, err := os.OpenFile(tmpPath, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	var checksum, etag string
+	err = f.Truncate(stat.Size)
+	if err == nil {
+		err = state.save()
+	}
+	if err == nil {
+		checksum, etag, err = c.downloadChunks(ctx, f, state, key, partSize, filepath.Base(path))
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// The partial file and its state are kept to continue later
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	if err := state.remove(); err != nil {
+		return nil, err
+	}
+	if expected := strings.Trim(stat.ETag, `"`); verifiable && etag != expected {
+		err := storage.FinishDownload(tmpPath, path, expected, etag)
+		return nil, fmt.Errorf("failed to verify etag: %w", err)
+	}
+	expected := stat.UserMetadata[checksumMetaHeader]
+	c.logger.Debug(fmt.Sprintf(
+		"got checksum: %s (expected: %s), etag verified: %t",
+		checksum,
+		expected,
+		verifiable,
+	))
+	if err := storage.FinishDownload(tmpPath, path, expected, checksum); err != nil {
+		return nil, err
+	}
+	return &storage.Download{
+		Bucket:     bucketName,
+		Object:     objectName,
+		Path:       path,
+		Size:       stat.Size,
+		Checksum:   checksum,
+		Verified:   expected != "",
+		Encryption: stat.UserMetadata[encryptionMetaHeader],
+	}, nil
+}
+
+// Fetches all unfinished chunks concurrently and writes them to f at their offsets,
+// every chunk has to match the ETag the download was started with. Finished chunks
+// are hashed in order while the download goes on, returns the SHA-256 and the ETag
+// of the content uploaded with partSize (see digester)
+func (c *MinioClient) downloadChunks(
+	ctx context.Context,
+	f *os.File,
+	state *downloadState,
+	key encrypt.ServerSide,
+	partSize int64,
+	name string,
+) (string, string, error) {
+	reporter := progress.New(c.progress, name, state.Size)
+	defer reporter.Finish()
+	finished := make([]chan struct{}, state.chunkCount())
+	for index := range finished {
+		finished[index] = make(chan struct{})
+		if state.hasChunk(index) {
+			reporter.Resume(state.chunkLength(index))
+			close(finished[index])
+		}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// Reads every chunk back once it is written, mostly from the page cache
+	digests := newDigester(partSize)
+	hashed := make(chan struct{})
+	go func() {
+		defer close(hashed)
+		for index, done := range finished {
+			select {
+			case <-done:
+			case <-ctx.Done():
+				return
+			}
+			offset := int64(index) * state.ChunkSize
+			chunk := io.NewSectionReader(f, offset, state.chunkLength(index))
+			if _, err := io.Copy(digests, chunk); err != nil {
+				cancel(fmt.Errorf("failed to compute checksum: %w", err))
+				return
+			}
+		}
+	}()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range downloadWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				if err := c.downloadChunk(ctx, f, state, key, index, reporter); err != nil {
+					cancel(err)
+					continue
+				}
+				// Skipped chunks of a canceled download are not finished
+				if state.hasChunk(index) {
+					close(finished[index])
+				}
+			}
+		}()
+	}
+	for index := range state.chunkCount() {
+		if state.hasChunk(index) {
+			continue
+		}
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	<-hashed
+	if err := context.Cause(ctx); err != nil {
+		return "", "", err
+	}
+	if err := f.Sync(); err != nil {
+		return "", "", err
+	}
+	checksum, etag := digests.sums()
+	return checksum, etag, nil
+}
+
+func (c *MinioClient) downloadChunk(
+	ctx context.Context,
+	f *os.File,
+	state *downloadState,
+	key encrypt.ServerSide,
+	index int,
+	reporter *progress.Reporter,
+) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	offset := int64(index) * state.ChunkSize
+	length := state.chunkLength(index)
+	opts := miniolib.GetObjectOptions{ServerSideEncryption: key}
+	if err := opts.SetRange(offset, offset+length-1); err != nil {
+		return err
+	}
+	// Fails if the object was replaced since the download was started
+	if err := opts.SetMatchETag(state.ETag); err != nil {
+		return err
+	}
+	obj, err := c.client.GetObject(ctx, state.Bucket, state.Object, opts)
+	if err != nil {
+		return fmt.Errorf("failed to get chunk %d: %w", index, err)
+	}
+	defer obj.Close()
+	n, err := io.Copy(io.NewOffsetWriter(f, offset), io.TeeReader(obj, reporter))
+	if err != nil {
+		return fmt.Errorf("failed to download chunk %d: %w", index, err)
+	}
+	if n != length {
+		return fmt.Errorf("failed to download chunk %d: got %d of %d bytes", index, n, length)
+	}
+	// The chunk has to be on disk before the state claims it is
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	return state.addChunk(index)
+}
+
+// Returns the part size the object was uploaded with (0 for a single request) and if
+// its ETag can be recomputed from the content, which is not the case for encrypted
+// objects or parts of different sizes
+func (c *MinioClient) etagPartSize(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+	stat miniolib.ObjectInfo,
+	key encrypt.ServerSide,
+) (int64, bool) {
+	if stat.Metadata.Get(sseHeader) != "" || stat.Metadata.Get(sseCustomerHeader) != "" {
+		return 0, false
+	}
+	etag := strings.Trim(stat.ETag, `"`)
+	_, count, multipart := strings.Cut(etag, "-")
+	if !multipart {
+		return 0, len(etag) == hex.EncodedLen(md5.Size)
+	}
+	parts, err := strconv.ParseInt(count, 10, 64)
+	if err != nil || parts < 1 {
+		return 0, false
+	}
+	first, err := c.client.StatObject(ctx, bucketName, objectName, miniolib.StatObjectOptions{
+		ServerSideEncryption: key,
+		PartNumber:           1,
+	})
+	if err != nil {
+		c.logger.Debug(fmt.Sprintf("failed to stat first part of %s: %s", objectName, err))
+		return 0, false
+	}
+	if first.Size <= 0 || (stat.Size+first.Size-1)/first.Size != parts {
+		return 0, false
+	}
+	return first.Size, true
+}
+
+// Computes the SHA-256 of the content written to it and the ETag S3 computes for
+// content uploaded with the given part size (0 for a single request)
+type digester struct {
+	hash     hash.Hash
+	partSize int64
+	// MD5 of the current part (of everything for single request uploads)
+	part     hash.Hash
+	partLen  int64
+	partSums []byte
+	parts    int
+}
+
+func newDigester(partSize int64) *digester {
+	return &digester{hash: sha256.New(), partSize: partSize, part: md5.New()}
+}
+
+func (d *digester) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		size := int64(len(p))
+		if d.partSize > 0 {
+			size = min(size, d.partSize-d.partLen)
+		}
+		d.hash.Write(p[:size])
+		d.part.Write(p[:size])
+		d.partLen += size
+		p = p[size:]
+		if d.partSize > 0 && d.partLen == d.partSize {
+			d.partSums = d.part.Sum(d.partSums)
+			d.parts++
+			d.part.Reset()
+			d.partLen = 0
+		}
+	}
+	return n, nil
+}
+
+// Returns the hex encoded SHA-256 and the ETag of everything written so far
+func (d *digester) sums() (string, string) {
+	checksum := hex.EncodeToString(d.hash.Sum(nil))
+	if d.partSize <= 0 {
+		return checksum, hex.EncodeToString(d.part.Sum(nil))
+	}
+	// Multipart ETags are the MD5 of the concatenated part MD5s and the part count
+	partSums, parts := d.partSums, d.parts
+	if d.partLen > 0 || parts == 0 {
+		partSums = d.part.Sum(partSums)
+		parts++
+	}
+	etag := md5.Sum(partSums)
+	return checksum, hex.EncodeToString(etag[:]) + "-" + strconv.Itoa(parts)
+}
+
+// Sidecar state of a ranged download next to its .part file so it can be continued
+type downloadState struct {
+	Bucket    string    `json:"bucket"`
+	Object    string    `json:"object"`
+	ETag      string    `json:"etag"`
+	Size      int64     `json:"size"`
+	ChunkSize int64     `json:"chunk_size"`
+	Chunks    []int     `json:"chunks"`
+	Started   time.Time `json:"started"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// Checks if the state still describes the object and its partial file on disk
+func (s *downloadState) matches(bucketName string, stat miniolib.ObjectInfo, tmpPath string) bool {
+	info, err := os.Stat(tmpPath)
+	if err != nil || info.Size() != stat.Size {
+		return false
+	}
+	return s.Bucket == bucketName && s.Object == stat.Key && s.ETag == stat.ETag &&
+		s.Size == stat.Size && s.ChunkSize == downloadChunkSize
+}
+
+func (s *downloadState) chunkCount() int {
+	return int((s.Size + s.ChunkSize - 1) / s.ChunkSize)
+}
+
+// Returns the length of the given chunk, only the last chunk may be shorter than ChunkSize
+func (s *downloadState) chunkLength(index int) int64 {
+	offset := int64(index) * s.ChunkSize
+	return max(min(s.ChunkSize, s.Size-offset), 0)
+}
+
+func (s *downloadState) hasChunk(index int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Contains(s.Chunks, index)
+}
+
+// Records a finished chunk and persists the state
+func (s *downloadState) addChunk(index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Chunks = append(s.Chunks, index)
+	return s.saveLocked()
+}
+
+func (s *downloadState) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveLocked()
+}
+
+func (s *downloadState) saveLocked() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal download state: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write download state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write download state: %w", err)
+	}
+	return nil
+}
+
+func (s *downloadState) remove() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove download state: %w", err)
+	}
+	return nil
+}
+
+func readDownloadState(path string) (*downloadState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s downloadState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal download state %s: %w", path, err)
+	}
+	s.path = path
+	return &s, nil
+}
+
+const (
+	// Size of the byte ranges fetched concurrently
+	downloadChunkSize int64 = 16 * 1024 * 1024
+	downloadWorkers   int   = 4
+	// Appended to the .part file of a download
+	downloadStateSuffix string = ".json"
+
+	sseHeader         string = "X-Amz-Server-Side-Encryption"
+	sseCustomerHeader string = "X-Amz-Server-Side-Encryption-Customer-Algorithm"
+)
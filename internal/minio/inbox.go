@@ -0,0 +1,177 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	miniolib "github.com/minio/minio-go/v7"
+
+	"github.com/devusSs/minio-link/internal/storage"
+)
+
+// RequestUpload creates a presigned PUT link for a single file or a POST policy for any
+// number of files, uploads are stored in the private bucket below storage.InboxPrefix
+func (c *MinioClient) RequestUpload(
+	ctx context.Context,
+	req storage.UploadRequest,
+) (*storage.UploadGrant, error) {
+	expiry := req.Expiry
+	if expiry <= 0 {
+		expiry = c.expiry
+	}
+	if expiry > maxPresignExpiry {
+		return nil, fmt.Errorf(
+			"expiry %s exceeds maximum of %s for presigned links",
+			expiry,
+			maxPresignExpiry,
+		)
+	}
+	if !req.Post && req.MaxSize > 0 {
+		return nil, fmt.Errorf("a maximum size can only be enforced by POST policies")
+	}
+	prefix, err := inboxPrefix(req.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.createBucket(ctx, false); err != nil {
+		return nil, err
+	}
+	grant := &storage.UploadGrant{
+		Bucket:      c.bucket(false),
+		Prefix:      prefix,
+		MaxSize:     req.MaxSize,
+		ContentType: req.ContentType,
+		Expires:     time.Now().Add(expiry),
+	}
+	if req.Post {
+		err = c.presignPost(ctx, grant)
+	} else {
+		err = c.presignPut(ctx, grant, req.Name, expiry)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Debug(fmt.Sprintf("requested %s upload to %s: %s", grant.Method, prefix, grant.URL))
+	return grant, nil
+}
+
+// ListInbox lists the files uploaded via requested links, newest first
+func (c *MinioClient) ListInbox(ctx context.Context, prefix string) ([]storage.InboxObject, error) {
+	bucketName := c.bucket(false)
+	opts := miniolib.ListObjectsOptions{
+		Prefix:    storage.InboxPrefix + strings.TrimPrefix(prefix, storage.InboxPrefix),
+		Recursive: true,
+	}
+	var objects []storage.InboxObject
+	for info := range c.client.ListObjects(ctx, bucketName, opts) {
+		if info.Err != nil {
+			if miniolib.ToErrorResponse(info.Err).Code == "NoSuchBucket" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to list objects: %w", info.Err)
+		}
+		link, err := c.getPrivateShareLink(ctx, bucketName, info.Key, c.expiry)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, storage.InboxObject{
+			Bucket:       bucketName,
+			Object:       info.Key,
+			Size:         info.Size,
+			LastModified: info.LastModified,
+			URL:          link,
+		})
+	}
+	slices.SortFunc(objects, func(a, b storage.InboxObject) int {
+		return b.LastModified.Compare(a.LastModified)
+	})
+	return objects, nil
+}
+
+// Signs a PUT link for a single file, a content type has to be sent by the uploader
+func (c *MinioClient) presignPut(
+	ctx context.Context,
+	grant *storage.UploadGrant,
+	name string,
+	expiry time.Duration,
+) error {
+	if name == "" {
+		name = storage.RandomObjectName()
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	headers := make(http.Header)
+	if grant.ContentType != "" {
+		headers.Set("Content-Type", grant.ContentType)
+	}
+	grant.Object = grant.Prefix + name
+	presignedURL, err := c.client.PresignHeader(
+		ctx,
+		http.MethodPut,
+		grant.Bucket,
+		grant.Object,
+		expiry,
+		nil,
+		headers,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get presigned url: %w", err)
+	}
+	grant.Method = http.MethodPut
+	grant.URL = presignedURL.String()
+	if grant.ContentType != "" {
+		grant.Headers = map[string]string{"Content-Type": grant.ContentType}
+	}
+	return nil
+}
+
+// Signs a POST policy for any number of files below the grant's prefix
+func (c *MinioClient) presignPost(ctx context.Context, grant *storage.UploadGrant) error {
+	policy := miniolib.NewPostPolicy()
+	if err := policy.SetBucket(grant.Bucket); err != nil {
+		return fmt.Errorf("failed to create post policy: %w", err)
+	}
+	if err := policy.SetKeyStartsWith(grant.Prefix); err != nil {
+		return fmt.Errorf("failed to create post policy: %w", err)
+	}
+	if err := policy.SetExpires(grant.Expires); err != nil {
+		return fmt.Errorf("failed to create post policy: %w", err)
+	}
+	if grant.MaxSize > 0 {
+		if err := policy.SetContentLengthRange(0, grant.MaxSize); err != nil {
+			return fmt.Errorf("failed to create post policy: %w", err)
+		}
+	}
+	if grant.ContentType != "" {
+		if err := policy.SetContentType(grant.ContentType); err != nil {
+			return fmt.Errorf("failed to create post policy: %w", err)
+		}
+	}
+	postURL, formData, err := c.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return fmt.Errorf("failed to presign post policy: %w", err)
+	}
+	// The server replaces ${filename} with the name of the uploaded file
+	formData["key"] = grant.Prefix + "${filename}"
+	grant.Method = http.MethodPost
+	grant.URL = postURL.String()
+	grant.FormData = formData
+	return nil
+}
+
+// Returns the key prefix of requested uploads (with trailing slash),
+// a random one if prefix is empty
+func inboxPrefix(prefix string) (string, error) {
+	prefix = strings.Trim(strings.TrimPrefix(prefix, storage.InboxPrefix), "/")
+	if prefix == "" {
+		prefix = storage.RandomObjectName()
+	}
+	if path.Clean(prefix) != prefix || strings.HasPrefix(prefix, "..") {
+		return "", fmt.Errorf("invalid prefix %q", prefix)
+	}
+	return storage.InboxPrefix + prefix + "/", nil
+}
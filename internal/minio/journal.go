@@ -0,0 +1,181 @@
+package minio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Local checkpoint of a multipart upload so it can be continued later
+type uploadJournal struct {
+	FilePath    string        `json:"file_path"`
+	Size        int64         `json:"size"`
+	ModTime     time.Time     `json:"mod_time"`
+	Bucket      string        `json:"bucket"`
+	Object      string        `json:"object"`
+	UploadID    string        `json:"upload_id"`
+	ContentType string        `json:"content_type"`
+	PartSize    int64         `json:"part_size"`
+	Parts       []journalPart `json:"parts"`
+	Started     time.Time     `json:"started"`
+	// Set if the upload was started with per part checksums
+	Checksums bool `json:"checksums"`
+	// Hex encoded SHA-256 of the whole file
+	Checksum string `json:"checksum,omitempty"`
+	// Base64 encoded SHA-256 of every part, in order
+	PartChecksums []string `json:"part_checksums,omitempty"`
+	// Server side encryption the upload was started with (environment.SSE format)
+	SSE string `json:"sse,omitempty"`
+	// Object lock the upload was started with
+	RetentionMode string    `json:"retention_mode,omitempty"`
+	RetainUntil   time.Time `json:"retain_until"`
+	LegalHold     bool      `json:"legal_hold,omitempty"`
+	// Expiry the upload was started with (RFC 3339), the tag is recomputed on completion
+	Expires string `json:"expires,omitempty"`
+
+	path string
+}
+
+type journalPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	// Base64 encoded SHA-256 of the part as verified by the server
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// Checks if the journal still describes the given file on disk
+func (j *uploadJournal) matches(info os.FileInfo) bool {
+	return j.Size == info.Size() && j.ModTime.Equal(info.ModTime())
+}
+
+// Records a finished part and persists the journal
+func (j *uploadJournal) addPart(number int, etag string, checksum string) error {
+	j.Parts = append(j.Parts, journalPart{Number: number, ETag: etag, Checksum: checksum})
+	return j.save()
+}
+
+// Returns the size of the given part, only the last part may be smaller than PartSize
+func (j *uploadJournal) partSize(number int) int64 {
+	offset := int64(number-1) * j.PartSize
+	return max(min(j.PartSize, j.Size-offset), 0)
+}
+
+func (j *uploadJournal) hasPart(number int) bool {
+	for _, p := range j.Parts {
+		if p.Number == number {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *uploadJournal) save() error {
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal: %w", err)
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+func (j *uploadJournal) remove() error {
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+	return nil
+}
+
+// Loads the journal for the given file, returns nil if there is none
+func loadJournal(dir string, filePath string) (*uploadJournal, error) {
+	path, err := journalPath(dir, filePath)
+	if err != nil {
+		return nil, err
+	}
+	j, err := readJournal(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return j, err
+}
+
+// Loads all journals stored in the given directory
+func loadJournals(dir string) ([]*uploadJournal, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, journalDirectory))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal directory: %w", err)
+	}
+	journals := make([]*uploadJournal, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		j, err := readJournal(filepath.Join(dir, journalDirectory, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		journals = append(journals, j)
+	}
+	return journals, nil
+}
+
+func newJournal(dir string, filePath string, info os.FileInfo) (*uploadJournal, error) {
+	path, err := journalPath(dir, filePath)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute file path: %w", err)
+	}
+	return &uploadJournal{
+		FilePath: abs,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Started:  time.Now(),
+		path:     path,
+	}, nil
+}
+
+func readJournal(path string) (*uploadJournal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var j uploadJournal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal journal %s: %w", path, err)
+	}
+	j.path = path
+	return &j, nil
+}
+
+// Journals are keyed by the absolute path of the uploaded file
+func journalPath(dir string, filePath string) (string, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute file path: %w", err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, journalDirectory, hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+const (
+	journalDirectory string = "uploads"
+)
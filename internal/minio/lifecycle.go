@@ -0,0 +1,202 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	miniolib "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/minio/minio-go/v7/pkg/tags"
+)
+
+// Adds the tag and metadata which let the bucket lifecycle delete the object after expires
+func (c *MinioClient) applyExpiry(
+	ctx context.Context,
+	bucketName string,
+	expires time.Time,
+	opts *miniolib.PutObjectOptions,
+) error {
+	if expires.IsZero() {
+		return nil
+	}
+	days, err := expiryDays(expires)
+	if err != nil {
+		return err
+	}
+	if err := c.ensureExpiryRule(ctx, bucketName, days); err != nil {
+		return err
+	}
+	if opts.UserTags == nil {
+		opts.UserTags = make(map[string]string)
+	}
+	if opts.UserMetadata == nil {
+		opts.UserMetadata = make(map[string]string)
+	}
+	opts.UserTags[expiryTagKey] = strconv.Itoa(days)
+	opts.UserMetadata[expiryMetaKey] = expires.UTC().Format(time.RFC3339)
+	return nil
+}
+
+// Recomputes the expiry tag of a completed multipart upload, lifecycle rules count the
+// days from the time the upload completed and not from when it was started
+func (c *MinioClient) refreshExpiry(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+	versionID string,
+	expiry string,
+	userTags map[string]string,
+	resumed bool,
+) error {
+	if expiry == "" {
+		return nil
+	}
+	expires, err := time.Parse(time.RFC3339, expiry)
+	if err != nil {
+		return fmt.Errorf("failed to parse expiry: %w", err)
+	}
+	days, err := expiryDays(expires)
+	if err != nil {
+		return err
+	}
+	// Resumed uploads were tagged when they were started, possibly days ago
+	if !resumed && userTags[expiryTagKey] == strconv.Itoa(days) {
+		return nil
+	}
+	if err := c.ensureExpiryRule(ctx, bucketName, days); err != nil {
+		return err
+	}
+	userTags = maps.Clone(userTags)
+	if userTags == nil {
+		userTags = make(map[string]string, 1)
+	}
+	userTags[expiryTagKey] = strconv.Itoa(days)
+	objectTags, err := tags.MapToObjectTags(userTags)
+	if err != nil {
+		return fmt.Errorf("failed to create tags: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("tagging %s/%s to expire in %d days", bucketName, objectName, days))
+	err = c.client.PutObjectTagging(
+		ctx,
+		bucketName,
+		objectName,
+		objectTags,
+		miniolib.PutObjectTaggingOptions{VersionID: versionID},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set expiry tag: %w", err)
+	}
+	return nil
+}
+
+// Makes sure the bucket has a lifecycle rule deleting objects tagged with the given days
+func (c *MinioClient) ensureExpiryRule(ctx context.Context, bucketName string, days int) error {
+	c.lifecycleMu.Lock()
+	defer c.lifecycleMu.Unlock()
+	config, err := c.getLifecycle(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+	if hasExpiryRule(config, days) {
+		return nil
+	}
+	config.Rules = append(config.Rules, expiryRule(days))
+	c.logger.Debug(fmt.Sprintf("adding lifecycle rule for %d days to %s", days, bucketName))
+	if err := c.client.SetBucketLifecycle(ctx, bucketName, config); err != nil {
+		return fmt.Errorf("failed to set bucket lifecycle: %w", err)
+	}
+	return nil
+}
+
+// Installs the default expiry rules, called when we create a bucket
+func (c *MinioClient) installExpiryRules(ctx context.Context, bucketName string) error {
+	c.lifecycleMu.Lock()
+	defer c.lifecycleMu.Unlock()
+	config, err := c.getLifecycle(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+	for _, days := range defaultExpiryDays {
+		if !hasExpiryRule(config, days) {
+			config.Rules = append(config.Rules, expiryRule(days))
+		}
+	}
+	if err := c.client.SetBucketLifecycle(ctx, bucketName, config); err != nil {
+		return fmt.Errorf("failed to set bucket lifecycle: %w", err)
+	}
+	return nil
+}
+
+func (c *MinioClient) getLifecycle(
+	ctx context.Context,
+	bucketName string,
+) (*lifecycle.Configuration, error) {
+	config, err := c.client.GetBucketLifecycle(ctx, bucketName)
+	if err != nil {
+		if miniolib.ToErrorResponse(err).Code == "NoSuchLifecycleConfiguration" {
+			return lifecycle.NewConfiguration(), nil
+		}
+		return nil, fmt.Errorf("failed to get bucket lifecycle: %w", err)
+	}
+	return config, nil
+}
+
+// Lifecycle rules only work with whole days, the days are rounded up to the next of
+// defaultExpiryDays so buckets never need more than a rule per step (S3 allows 1000 rules),
+// the exact expiry is kept in the object metadata
+func expiryDays(expires time.Time) (int, error) {
+	days := max(int(math.Ceil(time.Until(expires).Hours()/24)), 1)
+	i, _ := slices.BinarySearch(defaultExpiryDays, days)
+	if i == len(defaultExpiryDays) {
+		return 0, fmt.Errorf(
+			"expiry is more than %d days away",
+			defaultExpiryDays[len(defaultExpiryDays)-1],
+		)
+	}
+	return defaultExpiryDays[i], nil
+}
+
+func expiryRule(days int) lifecycle.Rule {
+	return lifecycle.Rule{
+		ID:     fmt.Sprintf("minio-link-expire-%dd", days),
+		Status: "Enabled",
+		RuleFilter: lifecycle.Filter{
+			Tag: lifecycle.Tag{Key: expiryTagKey, Value: strconv.Itoa(days)},
+		},
+		Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(days)},
+	}
+}
+
+func hasExpiryRule(config *lifecycle.Configuration, days int) bool {
+	id := expiryRule(days).ID
+	return slices.ContainsFunc(config.Rules, func(r lifecycle.Rule) bool { return r.ID == id })
+}
+
+// Parses the exact expiry stored in the object metadata
+func objectExpiry(info miniolib.ObjectInfo) (time.Time, bool) {
+	value, ok := info.UserMetadata[expiryMetaHeader]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+const (
+	expiryTagKey     string = "minio-link-expire-days"
+	expiryMetaKey    string = "minio-link-expires"
+	expiryMetaHeader string = "Minio-Link-Expires"
+)
+
+// Steps of the expiry rules, sorted
+var defaultExpiryDays = []int{
+	1, 2, 3, 4, 5, 6, 7, 10, 14, 21, 30, 45, 60, 90, 120, 180, 270, 365, 730, 1095, 1825, 3650,
+}
@@ -0,0 +1,47 @@
+package minio
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExpiryDays(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name    string
+		in      time.Duration
+		want    int
+		wantErr bool
+	}{
+		{name: "past", in: -time.Hour, want: 1},
+		{name: "an hour", in: time.Hour, want: 1},
+		{name: "just over a day", in: day + time.Hour, want: 2},
+		{name: "exact step", in: 7*day - time.Minute, want: 7},
+		{name: "between steps", in: 8 * day, want: 10},
+		{name: "a quarter", in: 100 * day, want: 120},
+		{name: "last step", in: 3650*day - time.Minute, want: 3650},
+		{name: "beyond last step", in: 3651 * day, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expiryDays(time.Now().Add(tt.in))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %d days, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get expiry days: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %d days, want %d", got, tt.want)
+			}
+		})
+	}
+	// The steps are searched binary
+	if !slices.IsSorted(defaultExpiryDays) {
+		t.Fatal("expiry steps are not sorted")
+	}
+}
@@ -1,138 +1,574 @@
 package minio
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gabriel-vasile/mimetype"
-	"github.com/google/uuid"
 	miniolib "github.com/minio/minio-go/v7"
 	credentials "github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 
 	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/storage"
 	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/progress"
 )
 
 // Wrapper around minio library
 type MinioClient struct {
 	logger        *log.Logger
 	client        *miniolib.Client
+	core          *miniolib.Core
+	stateDir      string
 	bucketName    string
 	bucketRegion  string
 	objectLocking bool
 	expiry        time.Duration
+	progress      progress.Mode
+
+	// Server side encryption of uploads, nil if disabled
+	sse       encrypt.ServerSide
+	sseConfig environment.SSE
+	bucketSSE bool
+
+	// Buckets already checked / created by createBucket
+	bucketsMu sync.Mutex
+	buckets   map[string]bool
+
+	lifecycleMu sync.Mutex
 }
 
-// UploadFile uploads a file to minio and returns the share link
+// SetProgress sets how transfer progress will be reported
+func (c *MinioClient) SetProgress(mode progress.Mode) {
+	c.progress = mode
+}
+
+// UploadFile uploads a file to minio and returns the upload including its share link,
+// interrupted uploads of the same file will be resumed
 func (c *MinioClient) UploadFile(
 	ctx context.Context,
 	filePath string,
 	public bool,
-) (string, error) {
+	opts storage.UploadOptions,
+) (*storage.Upload, error) {
+	return c.UploadFileAs(ctx, filePath, "", public, opts)
+}
+
+// UploadFileAs uploads a file to minio using the given object name
+// (random if empty) and returns the upload including its share link
+func (c *MinioClient) UploadFileAs(
+	ctx context.Context,
+	filePath string,
+	objectName string,
+	public bool,
+	opts storage.UploadOptions,
+) (*storage.Upload, error) {
 	c.logger.Debug(fmt.Sprintf("trying to upload file: %s (public: %t)", filePath, public))
 	if err := c.createBucket(ctx, public); err != nil {
-		return "", err
+		return nil, err
+	}
+	sums, err := c.fileSums(filePath)
+	if err != nil {
+		return nil, err
+	}
+	checksum := sums.checksum
+	c.logger.Debug(fmt.Sprintf("got checksum: %s", checksum))
+	bucketName := c.bucket(public)
+	// Objects with an expiry would not live as long as requested, locked ones would
+	// not be protected, named ones are part of a batch and have to exist under their name
+	if objectName == "" && opts.Expires.IsZero() && opts.Lock.IsZero() && !opts.ForceNew {
+		existing, err := c.findObject(ctx, bucketName, filePath, checksum)
+		if err != nil {
+			c.logger.Warn(fmt.Sprintf("failed to look for existing upload: %s", err))
+		}
+		if existing != nil {
+			c.logger.Debug(fmt.Sprintf("reusing existing object: %s", existing.Key))
+			return c.newUpload(ctx, opts, &storage.Upload{
+				FileName:    filepath.Base(filePath),
+				Bucket:      bucketName,
+				Object:      existing.Key,
+				Size:        existing.Size,
+				ContentType: existing.ContentType,
+				Checksum:    checksum,
+				Public:      public,
+				Existing:    true,
+			})
+		}
+	}
+	putOpts := miniolib.PutObjectOptions{
+		UserMetadata:         c.objectMetadata(checksum, opts),
+		ServerSideEncryption: c.sse,
 	}
-	contentType, err := findContentType(filePath)
+	if err := c.applyExpiry(ctx, bucketName, opts.Expires, &putOpts); err != nil {
+		return nil, err
+	}
+	if err := c.applyLock(ctx, bucketName, opts.Lock, &putOpts); err != nil {
+		return nil, err
+	}
+	info, contentType, err := c.multipartUpload(
+		ctx,
+		filePath,
+		sums,
+		bucketName,
+		objectName,
+		putOpts,
+	)
 	if err != nil {
-		return "", fmt.Errorf("failed to get mime type: %w", err)
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+	if objectName == "" && opts.Expires.IsZero() && opts.Lock.IsZero() {
+		if err := c.indexObject(ctx, bucketName, info.Key, checksum); err != nil {
+			c.logger.Warn(err.Error())
+		}
+	}
+	return c.newUpload(ctx, opts, &storage.Upload{
+		FileName:    filepath.Base(filePath),
+		Bucket:      bucketName,
+		Object:      info.Key,
+		Size:        info.Size,
+		ContentType: contentType,
+		Checksum:    checksum,
+		Public:      public,
+	})
+}
+
+// UploadData uploads data to minio using the given object name
+// and returns the upload including its share link
+func (c *MinioClient) UploadData(
+	ctx context.Context,
+	data []byte,
+	objectName string,
+	contentType string,
+	public bool,
+	opts storage.UploadOptions,
+) (*storage.Upload, error) {
+	c.logger.Debug(fmt.Sprintf("trying to upload data: %s (public: %t)", objectName, public))
+	if err := c.createBucket(ctx, public); err != nil {
+		return nil, err
+	}
+	bucketName := c.bucket(public)
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	putOpts := miniolib.PutObjectOptions{
+		ContentType:          contentType,
+		UserMetadata:         c.objectMetadata(checksum, opts),
+		ServerSideEncryption: c.sse,
+	}
+	// Verified by the server before the object is stored
+	putOpts.UserMetadata[miniolib.ChecksumSHA256.Key()] = base64.StdEncoding.EncodeToString(sum[:])
+	if err := c.applyExpiry(ctx, bucketName, opts.Expires, &putOpts); err != nil {
+		return nil, err
+	}
+	if err := c.applyLock(ctx, bucketName, opts.Lock, &putOpts); err != nil {
+		return nil, err
+	}
+	info, err := c.client.PutObject(
+		ctx,
+		bucketName,
+		objectName,
+		bytes.NewReader(data),
+		int64(len(data)),
+		putOpts,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload data: %w", err)
+	}
+	return c.newUpload(ctx, opts, &storage.Upload{
+		FileName:    path.Base(objectName),
+		Bucket:      bucketName,
+		Object:      info.Key,
+		Size:        int64(len(data)),
+		ContentType: contentType,
+		Checksum:    checksum,
+		Public:      public,
+	})
+}
+
+// UploadStream uploads everything read from r to minio and returns the upload
+// including its share link, the content type is sniffed from the first bytes
+// and name is only used for the extension
+func (c *MinioClient) UploadStream(
+	ctx context.Context,
+	r io.Reader,
+	name string,
+	public bool,
+	opts storage.UploadOptions,
+) (*storage.Upload, error) {
+	c.logger.Debug(fmt.Sprintf("trying to upload stream: %s (public: %t)", name, public))
+	if err := c.createBucket(ctx, public); err != nil {
+		return nil, err
+	}
+	bucketName := c.bucket(public)
+	// The checksum of the whole stream is not known upfront, every part is verified instead,
+	// without a part size minio-go buffers parts sized for the largest possible object
+	putOpts := miniolib.PutObjectOptions{
+		UserMetadata:         c.objectMetadata("", opts),
+		AutoChecksum:         miniolib.ChecksumSHA256,
+		ServerSideEncryption: c.sse,
+		PartSize:             uint64(defaultPartSize),
 	}
-	c.logger.Debug(fmt.Sprintf("got content type: %s", contentType))
-	fileName := randomiseFileName(filePath) + filepath.Ext(filePath)
+	if err := c.applyExpiry(ctx, bucketName, opts.Expires, &putOpts); err != nil {
+		return nil, err
+	}
+	if err := c.applyLock(ctx, bucketName, opts.Lock, &putOpts); err != nil {
+		return nil, err
+	}
+	head := make([]byte, storage.SniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	head = head[:n]
+	mime := mimetype.Detect(head)
+	c.logger.Debug(fmt.Sprintf("got content type: %s", mime.String()))
+	ext := filepath.Ext(name)
+	if ext == "" {
+		ext = mime.Extension()
+	}
+	fileName := storage.RandomObjectName() + ext
 	c.logger.Debug(fmt.Sprintf("generated file name: %s", fileName))
-	info, err := c.client.FPutObject(
+	hash := sha256.New()
+	reporter := progress.New(c.progress, name, opts.StreamSize())
+	putOpts.ContentType = mime.String()
+	putOpts.Progress = reporter
+	info, err := c.client.PutObject(
 		ctx,
-		c.bucketName,
+		bucketName,
 		fileName,
-		filePath,
-		miniolib.PutObjectOptions{ContentType: contentType},
+		io.TeeReader(io.MultiReader(bytes.NewReader(head), r), hash),
+		opts.StreamSize(),
+		putOpts,
 	)
+	reporter.Finish()
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file: %w", err)
+		return nil, fmt.Errorf("failed to upload stream: %w", err)
 	}
-	if public {
-		baseURL := c.client.EndpointURL().String()
-		finalURL := fmt.Sprintf("%s/%s/%s", baseURL, c.bucketName, info.Key)
-		c.logger.Debug(fmt.Sprintf("public link: %s", finalURL))
-		return finalURL, nil
+	if name == "" {
+		name = fileName
+	}
+	return c.newUpload(ctx, opts, &storage.Upload{
+		FileName:    name,
+		Bucket:      bucketName,
+		Object:      info.Key,
+		Size:        info.Size,
+		ContentType: mime.String(),
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		Public:      public,
+	})
+}
+
+// Returns the user metadata recorded for an upload, checksum may be empty if unknown
+func (c *MinioClient) objectMetadata(
+	checksum string,
+	opts storage.UploadOptions,
+) map[string]string {
+	metadata := make(map[string]string, 3)
+	if checksum != "" {
+		metadata[checksumMetaKey] = checksum
+	}
+	if opts.Encryption != "" {
+		metadata[encryptionMetaKey] = opts.Encryption
+	}
+	if c.sse != nil {
+		metadata[sseMetaKey] = c.sseConfig.Type
+	}
+	return metadata
+}
+
+// Fills in the share link and expiry of a finished upload
+func (c *MinioClient) newUpload(
+	ctx context.Context,
+	opts storage.UploadOptions,
+	upload *storage.Upload,
+) (*storage.Upload, error) {
+	expiry := c.expiry
+	if !opts.Expires.IsZero() {
+		upload.DeleteAt = opts.Expires
+		expiry = min(time.Until(opts.Expires), maxPresignExpiry)
 	}
-	link, err := c.getPrivateShareLink(ctx, fileName)
+	link, err := c.shareLink(ctx, upload.Bucket, upload.Object, upload.Public, expiry)
 	if err != nil {
-		return "", fmt.Errorf("failed to get private share link: %w", err)
+		return nil, err
 	}
-	c.logger.Debug(fmt.Sprintf("private link: %s", link))
-	return link, nil
+	upload.URL = link
+	upload.Encryption = opts.Encryption
+	if c.customerKey() != nil {
+		c.logger.Warn(fmt.Sprintf("%s is encrypted with SSE-C, links need the key", upload.Object))
+		upload.KeyRequired = true
+	}
+	if !upload.Public {
+		upload.Expires = time.Now().Add(expiry)
+	}
+	return upload, nil
 }
 
-// DownloadFile downloads a file from minio by the given input url,
+// DownloadFile downloads a file from minio by the given input url or object key,
 // if customPath = "" file path will be the same as the URL object path
-func (c *MinioClient) DownloadFile(ctx context.Context, input string, customPath string) error {
+func (c *MinioClient) DownloadFile(
+	ctx context.Context,
+	input string,
+	customPath string,
+) (*storage.Download, error) {
 	c.logger.Debug(fmt.Sprintf("trying to download file: %s", input))
-	u, err := url.Parse(input)
+	bucketName, objectName, err := c.ResolveObject(input)
 	if err != nil {
-		return fmt.Errorf("failed to parse url: %w", err)
-	}
-	c.logger.Debug(fmt.Sprintf("parsed url: %s", u.String()))
-	path := u.EscapedPath()
-	pathSplit := strings.Split(path, "/")
-	if len(pathSplit) < 2 {
-		return fmt.Errorf("invalid url, could not fetch bucket or object name")
-	}
-	bucketName := pathSplit[1]
-	objectName := pathSplit[2]
-	if bucketName == "" || objectName == "" {
-		return fmt.Errorf("invalid url, could not fetch bucket or object name")
+		return nil, err
 	}
 	c.logger.Debug(fmt.Sprintf("bucket name: %s, object name: %s", bucketName, objectName))
 	if customPath == "" {
-		customPath = "./files/" + objectName
+		customPath, err = storage.DefaultDownloadPath(objectName)
+		if err != nil {
+			return nil, err
+		}
 		c.logger.Debug(fmt.Sprintf("custom path not provided, using default: %s", customPath))
 	}
-	err = c.client.FGetObject(ctx, bucketName, objectName, customPath, miniolib.GetObjectOptions{})
-	return err
+	return c.getObjectToFile(ctx, bucketName, objectName, customPath)
 }
 
-// Gets objects from urls and checks if they still exist
-func (c *MinioClient) GetObjects(ctx context.Context, objectLinks []string) error {
-	fmt.Println("Objects uploaded using minio-link:")
-	fmt.Println("==================================")
-	for _, link := range objectLinks {
-		u, err := url.Parse(link)
-		if err != nil {
-			return fmt.Errorf("failed to parse url: %w", err)
+// DownloadToWriter streams a file from minio by the given input url or object key into w
+func (c *MinioClient) DownloadToWriter(ctx context.Context, input string, w io.Writer) error {
+	c.logger.Debug(fmt.Sprintf("trying to stream file: %s", input))
+	bucketName, objectName, err := c.ResolveObject(input)
+	if err != nil {
+		return err
+	}
+	c.logger.Debug(fmt.Sprintf("bucket name: %s, object name: %s", bucketName, objectName))
+	stat, key, err := c.statObject(ctx, bucketName, objectName)
+	if err != nil {
+		return fmt.Errorf("failed to stat object: %w", err)
+	}
+	obj, err := c.client.GetObject(ctx, bucketName, objectName, miniolib.GetObjectOptions{
+		ServerSideEncryption: key,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+	defer obj.Close()
+	reporter := progress.New(c.progress, objectName, stat.Size)
+	_, err = io.Copy(w, io.TeeReader(obj, reporter))
+	reporter.Finish()
+	if err != nil {
+		return fmt.Errorf("failed to stream file: %w", err)
+	}
+	return nil
+}
+
+// VerifyObject reads the object and returns its checksum together with the one
+// recorded on upload (empty for objects uploaded as a stream)
+func (c *MinioClient) VerifyObject(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+) (*storage.Verification, error) {
+	stat, key, err := c.statObject(ctx, bucketName, objectName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+	obj, err := c.client.GetObject(ctx, bucketName, objectName, miniolib.GetObjectOptions{
+		ServerSideEncryption: key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	defer obj.Close()
+	hash := sha256.New()
+	reporter := progress.New(c.progress, objectName, stat.Size)
+	_, err = io.Copy(hash, io.TeeReader(obj, reporter))
+	reporter.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	return &storage.Verification{
+		Bucket:   bucketName,
+		Object:   objectName,
+		Size:     stat.Size,
+		Expected: stat.UserMetadata[checksumMetaHeader],
+		Actual:   hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// ResolveObject resolves a minio url (public or presigned), "bucket/key" or a plain key
+// (assumed to be in the private bucket) to bucket and object name
+func (c *MinioClient) ResolveObject(input string) (string, string, error) {
+	if storage.IsLink(input) {
+		return parseObjectURL(input)
+	}
+	return storage.ResolveKey(input, c.bucketName)
+}
+
+// RenewLink creates a fresh presigned link for an existing private object,
+// if expiry <= 0 the configured default expiry will be used
+func (c *MinioClient) RenewLink(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+	expiry time.Duration,
+) (string, time.Time, error) {
+	if bucketName == c.bucket(true) {
+		return "", time.Time{}, fmt.Errorf("object %s is public, link does not expire", objectName)
+	}
+	if expiry <= 0 {
+		expiry = c.expiry
+	}
+	if expiry > maxPresignExpiry {
+		return "", time.Time{}, fmt.Errorf(
+			"expiry %s exceeds maximum of %s for presigned links",
+			expiry,
+			maxPresignExpiry,
+		)
+	}
+	_, key, err := c.statObject(ctx, bucketName, objectName)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+	if key != nil {
+		c.logger.Warn(fmt.Sprintf("%s is encrypted with SSE-C, links need the key", objectName))
+	}
+	presignedURL, err := c.client.PresignedGetObject(ctx, bucketName, objectName, expiry, nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get presigned url: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("renewed link for %s/%s: %s", bucketName, objectName, presignedURL))
+	return presignedURL.String(), time.Now().Add(expiry), nil
+}
+
+// DeleteObject removes an object from the given bucket,
+// objects which are already gone are no error
+func (c *MinioClient) DeleteObject(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+) error {
+	info, _, err := c.statObject(ctx, bucketName, objectName)
+	if err != nil {
+		// Expired or removed by hand, links and history entries may still be cleaned up
+		if miniolib.ToErrorResponse(err).Code == "NoSuchKey" {
+			c.logger.Debug(fmt.Sprintf("object %s/%s is already gone", bucketName, objectName))
+			return nil
 		}
-		path := u.EscapedPath()
-		pathSplit := strings.Split(path, "/")
-		if len(pathSplit) < 2 {
-			return fmt.Errorf("invalid url, could not fetch bucket or object name")
+		return fmt.Errorf("failed to stat object: %w", err)
+	}
+	if lock := objectLock(info); lock.Locked() {
+		return &storage.LockedError{Object: objectName, Lock: lock}
+	}
+	// Without the version locking buckets (which are versioned) would only add a
+	// delete marker and keep the object's data
+	err = c.client.RemoveObject(ctx, bucketName, objectName, miniolib.RemoveObjectOptions{
+		VersionID: info.VersionID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove object: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("removed object %s/%s", bucketName, objectName))
+	return nil
+}
+
+// StatObjects checks if the objects behind the share links still exist
+func (c *MinioClient) StatObjects(
+	ctx context.Context,
+	objectLinks []string,
+) []storage.ObjectStatus {
+	statuses := make([]storage.ObjectStatus, 0, len(objectLinks))
+	for _, link := range objectLinks {
+		status := storage.ObjectStatus{Link: link}
+		status.Bucket, status.Object, status.Err = parseObjectURL(link)
+		if status.Err != nil {
+			statuses = append(statuses, status)
+			continue
 		}
-		bucketName := pathSplit[1]
-		objectName := pathSplit[2]
-		if bucketName == "" || objectName == "" {
-			return fmt.Errorf("invalid url, could not fetch bucket or object name")
+		status.LinkExpires, _ = linkExpiry(link)
+		obj, _, err := c.statObject(ctx, status.Bucket, status.Object)
+		if err != nil {
+			if miniolib.ToErrorResponse(err).Code != "NoSuchKey" {
+				status.Err = fmt.Errorf("failed to get object: %w", err)
+			}
+			statuses = append(statuses, status)
+			continue
 		}
-		obj, err := c.client.StatObject(ctx, bucketName, objectName, miniolib.StatObjectOptions{})
+		status.Exists = true
+		status.Size = obj.Size
+		status.LastModified = obj.LastModified
+		status.DeleteAt, _ = objectExpiry(obj)
+		status.Lock = objectLock(obj)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Returns the public link or a presigned link for private objects
+func (c *MinioClient) shareLink(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+	public bool,
+	expiry time.Duration,
+) (string, error) {
+	if public {
+		baseURL := c.client.EndpointURL().String()
+		objectPath := (&url.URL{Path: bucketName + "/" + objectName}).EscapedPath()
+		finalURL := fmt.Sprintf("%s/%s", baseURL, objectPath)
+		c.logger.Debug(fmt.Sprintf("public link: %s", finalURL))
+		return finalURL, nil
+	}
+	link, err := c.getPrivateShareLink(ctx, bucketName, objectName, expiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to get private share link: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("private link: %s", link))
+	return link, nil
+}
+
+// CheckConnection checks endpoint and credentials via BucketExists and ListBuckets
+func (c *MinioClient) CheckConnection(ctx context.Context) (string, error) {
+	var missing []string
+	for _, bucketName := range []string{c.bucket(true), c.bucket(false)} {
+		exists, err := c.client.BucketExists(ctx, bucketName)
 		if err != nil {
-			return fmt.Errorf("failed to get object: %w", err)
+			return "", fmt.Errorf("failed to check if bucket exists: %w", err)
+		}
+		if !exists {
+			missing = append(missing, bucketName)
 		}
-		// TODO: improve design, add option to regen links
-		fmt.Printf(
-			"Object: %s - Bucket: %s - Expiry: %v - Last Modified: %v\n",
-			obj.Key,
-			bucketName,
-			obj.Expires,
-			obj.LastModified,
-		)
 	}
-	return nil
+	summary := "credentials valid"
+	buckets, err := c.client.ListBuckets(ctx)
+	if err != nil {
+		// Restricted keys may use their buckets without being allowed to list all of them
+		c.logger.Debug(fmt.Sprintf("failed to list buckets: %s", err))
+		summary += ", listing buckets not permitted"
+	} else {
+		summary += fmt.Sprintf(", %d bucket(s) visible", len(buckets))
+	}
+	if len(missing) > 0 {
+		summary += fmt.Sprintf(", %s will be created on first upload", strings.Join(missing, ", "))
+	}
+	return summary, nil
 }
 
-func (c *MinioClient) setBucketPublic(ctx context.Context, policy string) error {
-	err := c.client.SetBucketPolicy(ctx, c.bucketName, policy)
+// Returns the bucket name for public or private uploads
+func (c *MinioClient) bucket(public bool) string {
+	if !public {
+		return storage.PrivateBucket(c.bucketName)
+	}
+	return c.bucketName
+}
+
+func (c *MinioClient) setBucketPublic(ctx context.Context, bucketName string, policy string) error {
+	err := c.client.SetBucketPolicy(ctx, bucketName, policy)
 	if err != nil {
 		return fmt.Errorf("setting bucket policy: %w", err)
 	}
@@ -140,37 +576,54 @@ func (c *MinioClient) setBucketPublic(ctx context.Context, policy string) error
 }
 
 func (c *MinioClient) createBucket(ctx context.Context, public bool) error {
-	if !public {
-		c.bucketName += "-private"
+	bucketName := c.bucket(public)
+	c.bucketsMu.Lock()
+	defer c.bucketsMu.Unlock()
+	if c.buckets[bucketName] {
+		return nil
 	}
-	exists, err := c.client.BucketExists(ctx, c.bucketName)
+	exists, err := c.client.BucketExists(ctx, bucketName)
 	if err != nil {
 		return fmt.Errorf("failed to check if bucket exists: %w", err)
 	}
 	if !exists {
-		err = c.client.MakeBucket(ctx, c.bucketName, miniolib.MakeBucketOptions{
+		err = c.client.MakeBucket(ctx, bucketName, miniolib.MakeBucketOptions{
 			Region:        c.bucketRegion,
 			ObjectLocking: c.objectLocking,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to create bucket: %w", err)
 		}
+		if err := c.installExpiryRules(ctx, bucketName); err != nil {
+			c.logger.Warn(fmt.Sprintf("failed to install expiry rules: %s", err))
+		}
+		if c.bucketSSE {
+			if err := c.setBucketEncryption(ctx, bucketName); err != nil {
+				c.logger.Warn(fmt.Sprintf("failed to set default encryption: %s", err))
+			}
+		}
 	}
 	if public {
-		err := c.setBucketPublic(ctx, fmt.Sprintf(bucketPolicyPublic, c.bucketName))
+		err := c.setBucketPublic(ctx, bucketName, fmt.Sprintf(bucketPolicyPublic, bucketName))
 		if err != nil {
 			return fmt.Errorf("failed to set bucket policy: %w", err)
 		}
 	}
+	c.buckets[bucketName] = true
 	return nil
 }
 
-func (c *MinioClient) getPrivateShareLink(ctx context.Context, obj string) (string, error) {
+func (c *MinioClient) getPrivateShareLink(
+	ctx context.Context,
+	bucketName string,
+	obj string,
+	expiry time.Duration,
+) (string, error) {
 	presignedURL, err := c.client.PresignedGetObject(
 		ctx,
-		c.bucketName,
+		bucketName,
 		obj,
-		c.expiry,
+		expiry,
 		nil,
 	)
 	if err != nil {
@@ -193,17 +646,43 @@ func NewClient(dir string, debug bool, cfg *environment.EnvConfig) (*MinioClient
 	if err != nil {
 		return nil, fmt.Errorf("failed to create minio client: %w", err)
 	}
+	sseConfig, err := environment.ParseSSE(cfg.MinioSSE)
+	if err != nil {
+		return nil, err
+	}
+	serverSide, err := newServerSide(sseConfig)
+	if err != nil {
+		return nil, err
+	}
 	return &MinioClient{
 		logger:        logger,
 		client:        mClient,
+		core:          &miniolib.Core{Client: mClient},
+		stateDir:      filepath.Join(dir, "state"),
 		bucketName:    cfg.MinioBucketName,
 		bucketRegion:  cfg.MinioRegion,
 		objectLocking: cfg.MinioObjectLocking,
 		expiry:        cfg.MinioDefaultExpiry,
+		progress:      progress.ModeNone,
+		sse:           serverSide,
+		sseConfig:     sseConfig,
+		bucketSSE:     cfg.MinioBucketSSE,
+		buckets:       make(map[string]bool),
 	}, nil
 }
 
 const (
+	// Maximum lifetime of presigned links supported by S3
+	maxPresignExpiry time.Duration = 7 * 24 * time.Hour
+
+	// Client side encryption scheme of encrypted uploads
+	encryptionMetaKey    string = "minio-link-encryption"
+	encryptionMetaHeader string = "Minio-Link-Encryption"
+
+	// Server side encryption of uploads (environment.SSES3, SSEKMS or SSEC)
+	sseMetaKey    string = "minio-link-sse"
+	sseMetaHeader string = "Minio-Link-Sse"
+
 	bucketPolicyPublic string = `{
 		"Version": "2012-10-17",
 		"Statement": [
@@ -226,14 +705,38 @@ const (
 	}`
 )
 
-func findContentType(filePath string) (string, error) {
-	mime, err := mimetype.DetectFile(filePath)
+// Splits a (public or presigned) minio url into bucket and object name
+func parseObjectURL(input string) (string, string, error) {
+	u, err := url.Parse(input)
 	if err != nil {
-		return "", err
+		return "", "", fmt.Errorf("failed to parse url: %w", err)
 	}
-	return mime.String(), nil
+	pathSplit := strings.SplitN(u.Path, "/", 3)
+	if len(pathSplit) < 3 {
+		return "", "", fmt.Errorf("invalid url, could not fetch bucket or object name")
+	}
+	bucketName := pathSplit[1]
+	objectName := pathSplit[2]
+	if bucketName == "" || objectName == "" {
+		return "", "", fmt.Errorf("invalid url, could not fetch bucket or object name")
+	}
+	return bucketName, objectName, nil
 }
 
-func randomiseFileName(filePath string) string {
-	return uuid.New().String()
+// Reads the expiry of a presigned link from its query, public links do not expire
+func linkExpiry(link string) (time.Time, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return time.Time{}, false
+	}
+	query := u.Query()
+	signed, err := time.Parse("20060102T150405Z", query.Get("X-Amz-Date"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	seconds, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return signed.Add(time.Duration(seconds) * time.Second), true
 }
@@ -0,0 +1,434 @@
+package minio
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+
+	miniolib "github.com/minio/minio-go/v7"
+
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/progress"
+)
+
+// ListIncompleteUploads lists unfinished multipart uploads in the public and private bucket
+func (c *MinioClient) ListIncompleteUploads(
+	ctx context.Context,
+) ([]storage.IncompleteUpload, error) {
+	journals, err := loadJournals(c.stateDir)
+	if err != nil {
+		return nil, err
+	}
+	byUploadID := make(map[string]*uploadJournal, len(journals))
+	for _, j := range journals {
+		byUploadID[j.UploadID] = j
+	}
+	var uploads []storage.IncompleteUpload
+	for _, public := range []bool{true, false} {
+		bucketName := c.bucket(public)
+		exists, err := c.client.BucketExists(ctx, bucketName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if bucket exists: %w", err)
+		}
+		if !exists {
+			continue
+		}
+		for info := range c.client.ListIncompleteUploads(ctx, bucketName, "", true) {
+			if info.Err != nil {
+				return nil, fmt.Errorf("failed to list incomplete uploads: %w", info.Err)
+			}
+			upload := storage.IncompleteUpload{
+				Bucket:    bucketName,
+				Object:    info.Key,
+				UploadID:  info.UploadID,
+				Initiated: info.Initiated,
+				Public:    public,
+			}
+			if j, ok := byUploadID[info.UploadID]; ok {
+				upload.FilePath = j.FilePath
+			}
+			uploads = append(uploads, upload)
+		}
+	}
+	c.logger.Debug(fmt.Sprintf("found %d incomplete uploads", len(uploads)))
+	return uploads, nil
+}
+
+// AbortIncompleteUploads aborts unfinished multipart uploads and removes their journals,
+// if filePath != "" only the upload belonging to that file will be aborted
+func (c *MinioClient) AbortIncompleteUploads(ctx context.Context, filePath string) (int, error) {
+	var abs string
+	if filePath != "" {
+		var err error
+		abs, err = filepath.Abs(filePath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get absolute file path: %w", err)
+		}
+	}
+	uploads, err := c.ListIncompleteUploads(ctx)
+	if err != nil {
+		return 0, err
+	}
+	aborted := 0
+	for _, upload := range uploads {
+		if abs != "" && upload.FilePath != abs {
+			continue
+		}
+		c.logger.Debug(
+			fmt.Sprintf("aborting upload %s (%s/%s)", upload.UploadID, upload.Bucket, upload.Object),
+		)
+		err := c.core.AbortMultipartUpload(ctx, upload.Bucket, upload.Object, upload.UploadID)
+		if err != nil {
+			return aborted, fmt.Errorf("failed to abort upload of %s: %w", upload.Object, err)
+		}
+		aborted++
+	}
+	journals, err := loadJournals(c.stateDir)
+	if err != nil {
+		return aborted, err
+	}
+	for _, j := range journals {
+		if abs != "" && j.FilePath != abs {
+			continue
+		}
+		if err := j.remove(); err != nil {
+			return aborted, err
+		}
+	}
+	return aborted, nil
+}
+
+// Uploads the file in explicit parts and records every finished part in a journal,
+// an existing journal for the same unchanged file will be continued,
+// returns the upload info and the content type
+func (c *MinioClient) multipartUpload(
+	ctx context.Context,
+	filePath string,
+	sums *fileSums,
+	bucketName string,
+	objectName string,
+	opts miniolib.PutObjectOptions,
+) (miniolib.UploadInfo, string, error) {
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return miniolib.UploadInfo{}, "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	j, err := loadJournal(c.stateDir, filePath)
+	if err != nil {
+		return miniolib.UploadInfo{}, "", err
+	}
+	if j != nil && !c.resumable(ctx, j, stat, sums, bucketName, objectName, opts) {
+		c.discardJournal(ctx, j)
+		j = nil
+	}
+	resumed := j != nil
+	if j == nil {
+		j, err = c.startMultipartUpload(ctx, filePath, stat, sums, bucketName, objectName, opts)
+		if err != nil {
+			return miniolib.UploadInfo{}, "", err
+		}
+	} else {
+		c.logger.Debug(
+			fmt.Sprintf("resuming upload %s with %d finished parts", j.UploadID, len(j.Parts)),
+		)
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return miniolib.UploadInfo{}, "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	reporter := progress.New(c.progress, filepath.Base(filePath), j.Size)
+	defer reporter.Finish()
+	for _, p := range j.Parts {
+		reporter.Resume(j.partSize(p.Number))
+	}
+
+	totalParts := int((j.Size + j.PartSize - 1) / j.PartSize)
+	if totalParts == 0 {
+		totalParts = 1
+	}
+	for number := 1; number <= totalParts; number++ {
+		if j.hasPart(number) {
+			continue
+		}
+		offset := int64(number-1) * j.PartSize
+		size := j.partSize(number)
+		// Sent along so the server rejects parts which were corrupted on the way
+		// or changed since the file was hashed
+		checksum := j.PartChecksums[number-1]
+		header := make(http.Header)
+		header.Set(miniolib.ChecksumSHA256.Key(), checksum)
+		// Seekable so failed requests can be retried
+		section := io.NewSectionReader(f, offset, size)
+		part, err := c.core.PutObjectPart(
+			ctx,
+			j.Bucket,
+			j.Object,
+			j.UploadID,
+			number,
+			section,
+			size,
+			miniolib.PutObjectPartOptions{CustomHeader: header, SSE: c.customerKey()},
+		)
+		if err != nil {
+			return miniolib.UploadInfo{}, "", fmt.Errorf("failed to upload part %d: %w", number, err)
+		}
+		// Parts have no progress hook, retried requests would be counted twice anyway
+		reporter.Add(size)
+		if err := j.addPart(number, part.ETag, checksum); err != nil {
+			return miniolib.UploadInfo{}, "", err
+		}
+		c.logger.Debug(fmt.Sprintf("uploaded part %d/%d", number, totalParts))
+	}
+
+	sort.Slice(j.Parts, func(a, b int) bool { return j.Parts[a].Number < j.Parts[b].Number })
+	complete := make([]miniolib.CompletePart, 0, len(j.Parts))
+	for _, p := range j.Parts {
+		complete = append(complete, miniolib.CompletePart{
+			PartNumber:     p.Number,
+			ETag:           p.ETag,
+			ChecksumSHA256: p.Checksum,
+		})
+	}
+	info, err := c.core.CompleteMultipartUpload(
+		ctx,
+		j.Bucket,
+		j.Object,
+		j.UploadID,
+		complete,
+		miniolib.PutObjectOptions{
+			ContentType:          j.ContentType,
+			ServerSideEncryption: c.customerKey(),
+		},
+	)
+	if err != nil {
+		return miniolib.UploadInfo{}, "", fmt.Errorf("failed to complete upload: %w", err)
+	}
+	// The metadata keeps the expiry the upload was started with
+	err = c.refreshExpiry(
+		ctx,
+		j.Bucket,
+		j.Object,
+		info.VersionID,
+		j.Expires,
+		opts.UserTags,
+		resumed,
+	)
+	if err != nil {
+		return miniolib.UploadInfo{}, "", err
+	}
+	// Resumed uploads keep the retention they were started with, which may end earlier
+	if !opts.RetainUntilDate.IsZero() && opts.RetainUntilDate.After(j.RetainUntil) {
+		retention := miniolib.PutObjectRetentionOptions{
+			Mode:            &opts.Mode,
+			RetainUntilDate: &opts.RetainUntilDate,
+			VersionID:       info.VersionID,
+		}
+		if err := c.client.PutObjectRetention(ctx, j.Bucket, j.Object, retention); err != nil {
+			return miniolib.UploadInfo{}, "", fmt.Errorf("failed to extend retention: %w", err)
+		}
+	}
+	if err := j.remove(); err != nil {
+		return miniolib.UploadInfo{}, "", err
+	}
+	info.Size = j.Size
+	return info, j.ContentType, nil
+}
+
+func (c *MinioClient) startMultipartUpload(
+	ctx context.Context,
+	filePath string,
+	stat os.FileInfo,
+	sums *fileSums,
+	bucketName string,
+	objectName string,
+	opts miniolib.PutObjectOptions,
+) (*uploadJournal, error) {
+	j, err := newJournal(c.stateDir, filePath, stat)
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := storage.ContentType(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mime type: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("got content type: %s", contentType))
+	j.Bucket = bucketName
+	j.Object = objectName
+	if j.Object == "" {
+		j.Object = storage.RandomObjectName() + filepath.Ext(filePath)
+	}
+	j.ContentType = contentType
+	j.PartSize = sums.partSize
+	j.Checksum = sums.checksum
+	j.PartChecksums = sums.parts
+	c.logger.Debug(fmt.Sprintf("generated file name: %s", j.Object))
+	j.Checksums = true
+	j.SSE = c.sseConfig.String()
+	j.RetentionMode = string(opts.Mode)
+	j.RetainUntil = opts.RetainUntilDate
+	j.LegalHold = opts.LegalHold == miniolib.LegalHoldEnabled
+	j.Expires = opts.UserMetadata[expiryMetaKey]
+	opts.ContentType = contentType
+	opts.UserMetadata = maps.Clone(opts.UserMetadata)
+	if opts.UserMetadata == nil {
+		opts.UserMetadata = make(map[string]string, 1)
+	}
+	opts.UserMetadata[checksumAlgorithmHeader] = miniolib.ChecksumSHA256.String()
+	j.UploadID, err = c.core.NewMultipartUpload(ctx, j.Bucket, j.Object, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	if err := j.save(); err != nil {
+		return nil, err
+	}
+	c.logger.Debug(fmt.Sprintf("started multipart upload: %s", j.UploadID))
+	return j, nil
+}
+
+// Checks if the journal can be continued and syncs its parts with the server
+func (c *MinioClient) resumable(
+	ctx context.Context,
+	j *uploadJournal,
+	stat os.FileInfo,
+	sums *fileSums,
+	bucketName string,
+	objectName string,
+	opts miniolib.PutObjectOptions,
+) bool {
+	if !j.matches(stat) || j.Bucket != bucketName || j.PartSize != sums.partSize ||
+		!j.Checksums || j.Checksum != sums.checksum ||
+		(objectName != "" && j.Object != objectName) {
+		c.logger.Debug("journal does not match file anymore, starting over")
+		return false
+	}
+	// Parts of SSE-C uploads can only be combined with the same key
+	if j.SSE != c.sseConfig.String() {
+		c.logger.Debug("journal was started with a different encryption, starting over")
+		return false
+	}
+	if j.RetentionMode != string(opts.Mode) ||
+		j.LegalHold != (opts.LegalHold == miniolib.LegalHoldEnabled) {
+		c.logger.Debug("journal was started with a different object lock, starting over")
+		return false
+	}
+	// Servers not returning part checksums keep the ones we sent
+	checksums := make(map[int]string, len(j.Parts))
+	for _, p := range j.Parts {
+		checksums[p.Number] = p.Checksum
+	}
+	var parts []journalPart
+	marker := 0
+	for {
+		res, err := c.core.ListObjectParts(ctx, j.Bucket, j.Object, j.UploadID, marker, 1000)
+		if err != nil {
+			c.logger.Debug(fmt.Sprintf("failed to list uploaded parts, starting over: %s", err))
+			return false
+		}
+		for _, p := range res.ObjectParts {
+			checksum := p.ChecksumSHA256
+			if checksum == "" {
+				checksum = checksums[p.PartNumber]
+			}
+			if checksum == "" {
+				c.logger.Debug(
+					fmt.Sprintf("missing checksum of part %d, starting over", p.PartNumber),
+				)
+				return false
+			}
+			parts = append(parts, journalPart{
+				Number:   p.PartNumber,
+				ETag:     p.ETag,
+				Checksum: checksum,
+			})
+		}
+		if !res.IsTruncated {
+			break
+		}
+		marker = res.NextPartNumberMarker
+	}
+	j.Parts = parts
+	return true
+}
+
+// SHA-256 checksums of a file and of the parts it is uploaded in
+type fileSums struct {
+	// Hex encoded checksum of the whole file
+	checksum string
+	partSize int64
+	// Base64 encoded checksum of every part
+	parts []string
+}
+
+// Hashes the file and its parts in a single pass, the checksums of a file with an
+// unchanged journal are taken from the journal instead of reading the file again
+func (c *MinioClient) fileSums(filePath string) (*fileSums, error) {
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	_, partSize, _, err := miniolib.OptimalPartInfo(stat.Size(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate part size: %w", err)
+	}
+	// OptimalPartInfo only scales up for huge files, small files get our default
+	partSize = max(partSize, defaultPartSize)
+	totalParts := max(int((stat.Size()+partSize-1)/partSize), 1)
+
+	j, err := loadJournal(c.stateDir, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if j != nil && j.matches(stat) && j.PartSize == partSize && j.Checksum != "" &&
+		len(j.PartChecksums) == totalParts {
+		c.logger.Debug("using checksums of the upload journal")
+		return &fileSums{checksum: j.Checksum, partSize: partSize, parts: j.PartChecksums}, nil
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	total := sha256.New()
+	sums := &fileSums{partSize: partSize, parts: make([]string, 0, totalParts)}
+	for number := 1; number <= totalParts; number++ {
+		part := sha256.New()
+		size := min(partSize, stat.Size()-int64(number-1)*partSize)
+		if _, err := io.CopyN(io.MultiWriter(total, part), f, size); err != nil {
+			return nil, fmt.Errorf("failed to compute checksum of part %d: %w", number, err)
+		}
+		sums.parts = append(sums.parts, base64.StdEncoding.EncodeToString(part.Sum(nil)))
+	}
+	sums.checksum = hex.EncodeToString(total.Sum(nil))
+	return sums, nil
+}
+
+// Aborts the upload belonging to the journal (best effort) and removes the journal
+func (c *MinioClient) discardJournal(ctx context.Context, j *uploadJournal) {
+	if j.UploadID != "" {
+		err := c.core.AbortMultipartUpload(ctx, j.Bucket, j.Object, j.UploadID)
+		if err != nil {
+			c.logger.Debug(fmt.Sprintf("failed to abort stale upload: %s", err))
+		}
+	}
+	if err := j.remove(); err != nil {
+		c.logger.Debug(err.Error())
+	}
+}
+
+const (
+	defaultPartSize int64 = 16 * 1024 * 1024
+	// Sent as metadata to start uploads expecting a checksum with every part
+	checksumAlgorithmHeader string = "X-Amz-Checksum-Algorithm"
+)
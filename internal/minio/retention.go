@@ -0,0 +1,192 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	miniolib "github.com/minio/minio-go/v7"
+
+	"github.com/devusSs/minio-link/internal/storage"
+)
+
+// GetLock returns the retention and legal hold of an object
+func (c *MinioClient) GetLock(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+) (storage.Lock, error) {
+	info, _, err := c.statObject(ctx, bucketName, objectName)
+	if err != nil {
+		return storage.Lock{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+	return objectLock(info), nil
+}
+
+// SetRetention protects an object from being removed until the given time,
+// governance retentions may be shortened (with the permission to bypass them)
+func (c *MinioClient) SetRetention(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+	mode string,
+	until time.Time,
+) error {
+	if err := c.requireObjectLocking(ctx, bucketName); err != nil {
+		return err
+	}
+	current, err := c.GetLock(ctx, bucketName, objectName)
+	if err != nil {
+		return err
+	}
+	if current.Mode == storage.RetentionCompliance && current.Retained() &&
+		(mode != storage.RetentionCompliance || until.Before(current.RetainUntil)) {
+		return fmt.Errorf(
+			"compliance retention of %s can only be extended until %s",
+			objectName,
+			current.RetainUntil.Local().Format(time.RFC1123),
+		)
+	}
+	// Only shortening or changing a governance retention has to bypass it
+	bypass := current.Mode == storage.RetentionGovernance && current.Retained() &&
+		(mode != storage.RetentionGovernance || until.Before(current.RetainUntil))
+	retentionMode := retentionModes[mode]
+	opts := miniolib.PutObjectRetentionOptions{
+		GovernanceBypass: bypass,
+		Mode:             &retentionMode,
+		RetainUntilDate:  &until,
+	}
+	if err := c.client.PutObjectRetention(ctx, bucketName, objectName, opts); err != nil {
+		return fmt.Errorf("failed to set retention: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("set %s retention of %s until %s", mode, objectName, until))
+	return nil
+}
+
+// ClearRetention removes a governance retention, which requires the permission
+// to bypass it, compliance retentions can not be removed before they expire
+func (c *MinioClient) ClearRetention(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+) error {
+	current, err := c.GetLock(ctx, bucketName, objectName)
+	if err != nil {
+		return err
+	}
+	if current.Mode == "" {
+		return nil
+	}
+	if current.Mode == storage.RetentionCompliance && current.Retained() {
+		return fmt.Errorf(
+			"compliance retention of %s can not be removed before %s",
+			objectName,
+			current.RetainUntil.Local().Format(time.RFC1123),
+		)
+	}
+	opts := miniolib.PutObjectRetentionOptions{GovernanceBypass: true}
+	if err := c.client.PutObjectRetention(ctx, bucketName, objectName, opts); err != nil {
+		return fmt.Errorf("failed to clear retention: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("cleared retention of %s", objectName))
+	return nil
+}
+
+// SetLegalHold enables or disables the legal hold of an object
+func (c *MinioClient) SetLegalHold(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+	enabled bool,
+) error {
+	if enabled {
+		if err := c.requireObjectLocking(ctx, bucketName); err != nil {
+			return err
+		}
+	}
+	status := miniolib.LegalHoldDisabled
+	if enabled {
+		status = miniolib.LegalHoldEnabled
+	}
+	opts := miniolib.PutObjectLegalHoldOptions{Status: &status}
+	if err := c.client.PutObjectLegalHold(ctx, bucketName, objectName, opts); err != nil {
+		return fmt.Errorf("failed to set legal hold: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("set legal hold of %s: %t", objectName, enabled))
+	return nil
+}
+
+// Adds the retention and legal hold headers to a new object
+func (c *MinioClient) applyLock(
+	ctx context.Context,
+	bucketName string,
+	lock storage.Lock,
+	opts *miniolib.PutObjectOptions,
+) error {
+	if lock.IsZero() {
+		return nil
+	}
+	if err := c.requireObjectLocking(ctx, bucketName); err != nil {
+		return err
+	}
+	if lock.Mode != "" {
+		opts.Mode = retentionModes[lock.Mode]
+		opts.RetainUntilDate = lock.RetainUntil
+	}
+	if lock.LegalHold {
+		opts.LegalHold = miniolib.LegalHoldEnabled
+	}
+	return nil
+}
+
+// Fails if the bucket was not created with object locking, which can not be enabled later
+func (c *MinioClient) requireObjectLocking(ctx context.Context, bucketName string) error {
+	enabled, _, _, _, err := c.client.GetObjectLockConfig(ctx, bucketName)
+	if err != nil && miniolib.ToErrorResponse(err).Code != lockConfigNotFound {
+		return fmt.Errorf("failed to get object lock config: %w", err)
+	}
+	if enabled != "Enabled" {
+		return fmt.Errorf(
+			"bucket %s does not support object locks, it has to be created with "+
+				"LINK_MINIO_OBJECT_LOCKING=true",
+			bucketName,
+		)
+	}
+	return nil
+}
+
+// Reads the retention and legal hold from the headers returned by StatObject
+func objectLock(info miniolib.ObjectInfo) storage.Lock {
+	var lock storage.Lock
+	switch miniolib.RetentionMode(strings.ToUpper(info.Metadata.Get(lockModeHeader))) {
+	case miniolib.Governance:
+		lock.Mode = storage.RetentionGovernance
+	case miniolib.Compliance:
+		lock.Mode = storage.RetentionCompliance
+	}
+	until, err := time.Parse(time.RFC3339, info.Metadata.Get(lockRetainUntilHeader))
+	if err == nil {
+		lock.RetainUntil = until
+	}
+	lock.LegalHold = strings.EqualFold(
+		info.Metadata.Get(lockLegalHoldHeader),
+		string(miniolib.LegalHoldEnabled),
+	)
+	return lock
+}
+
+var (
+	retentionModes = map[string]miniolib.RetentionMode{
+		storage.RetentionGovernance: miniolib.Governance,
+		storage.RetentionCompliance: miniolib.Compliance,
+	}
+)
+
+const (
+	lockModeHeader        string = "X-Amz-Object-Lock-Mode"
+	lockRetainUntilHeader string = "X-Amz-Object-Lock-Retain-Until-Date"
+	lockLegalHoldHeader   string = "X-Amz-Object-Lock-Legal-Hold"
+
+	lockConfigNotFound string = "ObjectLockConfigurationNotFoundError"
+)
@@ -0,0 +1,129 @@
+package minio
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	miniolib "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/minio-go/v7/pkg/sse"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+)
+
+// Returns the server side encryption for uploads, nil if they are not encrypted
+func newServerSide(cfg environment.SSE) (encrypt.ServerSide, error) {
+	switch cfg.Type {
+	case environment.SSES3:
+		return encrypt.NewSSE(), nil
+	case environment.SSEKMS:
+		serverSide, err := encrypt.NewSSEKMS(cfg.KeyID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SSE-KMS encryption: %w", err)
+		}
+		return serverSide, nil
+	case environment.SSEC:
+		key, err := readCustomerKey(cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		serverSide, err := encrypt.NewSSEC(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SSE-C encryption: %w", err)
+		}
+		return serverSide, nil
+	}
+	return nil, nil
+}
+
+// Reads a SSE-C key file containing 32 raw bytes or their hex / base64 encoding
+// (e.g. created by "openssl rand 32 > key" or "openssl rand -base64 32 > key")
+func readCustomerKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSE-C key file: %w", err)
+	}
+	if len(data) == customerKeySize {
+		return data, nil
+	}
+	text := string(bytes.TrimSpace(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == customerKeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil &&
+		len(key) == customerKeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf(
+		"SSE-C key file %s must contain %d bytes (raw, hex or base64)",
+		path,
+		customerKeySize,
+	)
+}
+
+// Returns the SSE-C key needed to read objects and to complete multipart uploads,
+// nil if uploads are not encrypted with a customer key
+func (c *MinioClient) customerKey() encrypt.ServerSide {
+	if c.sse == nil || c.sse.Type() != encrypt.SSEC {
+		return nil
+	}
+	return c.sse
+}
+
+// Stats the object and returns the encryption reading it requires, objects encrypted
+// with SSE-C can only be read with their key while other objects must be read without
+func (c *MinioClient) statObject(
+	ctx context.Context,
+	bucketName string,
+	objectName string,
+) (miniolib.ObjectInfo, encrypt.ServerSide, error) {
+	key := c.customerKey()
+	if key == nil {
+		info, err := c.client.StatObject(ctx, bucketName, objectName, miniolib.StatObjectOptions{})
+		return info, nil, err
+	}
+	info, err := c.client.StatObject(ctx, bucketName, objectName, miniolib.StatObjectOptions{
+		ServerSideEncryption: key,
+	})
+	if err == nil {
+		return info, key, nil
+	}
+	c.logger.Debug(fmt.Sprintf("failed to stat %s with SSE-C key: %s", objectName, err))
+	info, plainErr := c.client.StatObject(
+		ctx,
+		bucketName,
+		objectName,
+		miniolib.StatObjectOptions{},
+	)
+	if plainErr != nil {
+		// The first error tells about wrong keys, both are the same for missing objects
+		return miniolib.ObjectInfo{}, nil, err
+	}
+	return info, nil, nil
+}
+
+// Sets the configured encryption as default of a new bucket,
+// objects uploaded by other clients are then encrypted as well
+func (c *MinioClient) setBucketEncryption(ctx context.Context, bucketName string) error {
+	var config *sse.Configuration
+	switch c.sseConfig.Type {
+	case environment.SSES3:
+		config = sse.NewConfigurationSSES3()
+	case environment.SSEKMS:
+		config = sse.NewConfigurationSSEKMS(c.sseConfig.KeyID)
+	default:
+		return fmt.Errorf("default bucket encryption only supports SSE-S3 and SSE-KMS")
+	}
+	if err := c.client.SetBucketEncryption(ctx, bucketName, config); err != nil {
+		return fmt.Errorf("failed to set bucket encryption: %w", err)
+	}
+	return nil
+}
+
+const (
+	customerKeySize int = 32
+)
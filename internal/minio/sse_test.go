@@ -0,0 +1,60 @@
+package minio
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCustomerKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, customerKeySize)
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{name: "raw", data: key},
+		{name: "hex", data: []byte(hex.EncodeToString(key))},
+		{name: "hex with newline", data: []byte(hex.EncodeToString(key) + "\n")},
+		{name: "base64", data: []byte(base64.StdEncoding.EncodeToString(key))},
+		{name: "base64 with newline", data: []byte(base64.StdEncoding.EncodeToString(key) + "\n")},
+		{name: "empty", data: nil, wantErr: true},
+		{name: "raw too short", data: key[:customerKeySize-1], wantErr: true},
+		// 16 bytes would be hex encoded to 32 characters, which are taken as a raw key
+		{name: "hex too short", data: []byte(hex.EncodeToString(key[:20])), wantErr: true},
+		{
+			name:    "base64 too long",
+			data:    []byte(base64.StdEncoding.EncodeToString(append(key, 0))),
+			wantErr: true,
+		},
+		{name: "text", data: []byte("not a key"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sse.key")
+			if err := os.WriteFile(path, tt.data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readCustomerKey(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got key %x, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to read key: %v", err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Fatalf("got key %x, want %x", got, key)
+			}
+		})
+	}
+
+	if _, err := readCustomerKey(filepath.Join(t.TempDir(), "missing.key")); err == nil {
+		t.Fatal("got no error for a missing key file")
+	}
+}
@@ -0,0 +1,801 @@
+package results
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/devusSs/minio-link/internal/history"
+)
+
+// Upload is a single uploaded file
+type Upload struct {
+	File        string     `json:"file" yaml:"file"`
+	Bucket      string     `json:"bucket,omitempty" yaml:"bucket,omitempty"`
+	Object      string     `json:"object,omitempty" yaml:"object,omitempty"`
+	Public      bool       `json:"public" yaml:"public"`
+	Size        int64      `json:"size" yaml:"size"`
+	ContentType string     `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+	Checksum    string     `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	MinioURL    string     `json:"minio_url,omitempty" yaml:"minio_url,omitempty"`
+	ShortURL    string     `json:"short_url,omitempty" yaml:"short_url,omitempty"`
+	Expires     *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+	DeleteAt    *time.Time `json:"delete_at,omitempty" yaml:"delete_at,omitempty"`
+	Existing    bool       `json:"existing,omitempty" yaml:"existing,omitempty"`
+	Encryption  string     `json:"encryption,omitempty" yaml:"encryption,omitempty"`
+	Key         string     `json:"key,omitempty" yaml:"key,omitempty"`
+	Error       string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Link returns the short url if there is one, else the minio url
+func (u *Upload) Link() string {
+	if u.ShortURL != "" {
+		return u.ShortURL
+	}
+	return u.MinioURL
+}
+
+// UploadResult is the result of the upload command
+type UploadResult struct {
+	Files []Upload `json:"files" yaml:"files"`
+	// Index page of a multi file upload
+	Index *Upload `json:"index,omitempty" yaml:"index,omitempty"`
+	// Link to share, either the single file's or the index page's short link
+	ShortURL string `json:"short_url,omitempty" yaml:"short_url,omitempty"`
+}
+
+func (r *UploadResult) Header() []string {
+	return []string{"FILE", "SIZE", "VISIBILITY", "EXPIRES", "LINK"}
+}
+
+func (r *UploadResult) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Files)+1)
+	for _, f := range append(r.Files, r.indexRows()...) {
+		link := f.Link()
+		if f.Error != "" {
+			link = "FAILED: " + f.Error
+		} else if f.Key != "" && !strings.Contains(link, "#") {
+			link += " (key: " + f.Key + ")"
+		}
+		rows = append(rows, []string{
+			f.File,
+			humanize.IBytes(uint64(f.Size)),
+			visibility(f.Public),
+			formatTime(f.Expires),
+			link,
+		})
+	}
+	return rows
+}
+
+func (r *UploadResult) Plain() []string {
+	if r.ShortURL != "" {
+		// The key of an encrypted upload has to be shared as well
+		if len(r.Files) == 1 && r.Files[0].Key != "" && !strings.Contains(r.ShortURL, "#") {
+			return []string{r.ShortURL, r.Files[0].Key}
+		}
+		return []string{r.ShortURL}
+	}
+	lines := make([]string, 0, len(r.Files))
+	for _, f := range r.Files {
+		if f.Error == "" {
+			lines = append(lines, f.Link())
+		}
+	}
+	return lines
+}
+
+func (r *UploadResult) indexRows() []Upload {
+	if r.Index == nil {
+		return nil
+	}
+	return []Upload{*r.Index}
+}
+
+// IncompleteUpload is an unfinished multipart upload
+type IncompleteUpload struct {
+	Bucket    string    `json:"bucket" yaml:"bucket"`
+	Object    string    `json:"object" yaml:"object"`
+	UploadID  string    `json:"upload_id" yaml:"upload_id"`
+	Initiated time.Time `json:"initiated" yaml:"initiated"`
+	File      string    `json:"file,omitempty" yaml:"file,omitempty"`
+	ShortURL  string    `json:"short_url,omitempty" yaml:"short_url,omitempty"`
+	Error     string    `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// IncompleteUploadsResult is the result of upload --resume and --abort
+type IncompleteUploadsResult struct {
+	Uploads []IncompleteUpload `json:"uploads" yaml:"uploads"`
+	Aborted int                `json:"aborted,omitempty" yaml:"aborted,omitempty"`
+}
+
+func (r *IncompleteUploadsResult) Header() []string {
+	return []string{"OBJECT", "BUCKET", "STARTED", "FILE", "STATUS"}
+}
+
+func (r *IncompleteUploadsResult) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Uploads))
+	for _, u := range r.Uploads {
+		status := "no local journal, use --abort"
+		switch {
+		case u.Error != "":
+			status = "FAILED: " + u.Error
+		case u.ShortURL != "":
+			status = "resumed: " + u.ShortURL
+		case u.File != "":
+			status = "pending"
+		}
+		rows = append(rows, []string{
+			u.Object,
+			u.Bucket,
+			u.Initiated.Local().Format(timeFormat),
+			u.File,
+			status,
+		})
+	}
+	if r.Aborted > 0 {
+		aborted := fmt.Sprintf("aborted %d upload(s)", r.Aborted)
+		rows = append(rows, []string{"", "", "", "", aborted})
+	}
+	return rows
+}
+
+// DownloadResult is the result of the download command
+type DownloadResult struct {
+	Link      string `json:"link" yaml:"link"`
+	MinioURL  string `json:"minio_url" yaml:"minio_url"`
+	Bucket    string `json:"bucket" yaml:"bucket"`
+	Object    string `json:"object" yaml:"object"`
+	Path      string `json:"path,omitempty" yaml:"path,omitempty"`
+	Size      int64  `json:"size" yaml:"size"`
+	Checksum  string `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	Verified  bool   `json:"verified" yaml:"verified"`
+	Decrypted bool   `json:"decrypted,omitempty" yaml:"decrypted,omitempty"`
+}
+
+func (r *DownloadResult) Header() []string {
+	return []string{"OBJECT", "SIZE", "PATH", "CHECKSUM"}
+}
+
+func (r *DownloadResult) Rows() [][]string {
+	checksum := "not verified"
+	if r.Verified {
+		checksum = "verified"
+	}
+	return [][]string{{r.Object, humanize.IBytes(uint64(r.Size)), r.Path, checksum}}
+}
+
+func (r *DownloadResult) Plain() []string {
+	return []string{r.Path}
+}
+
+// ListEntry is a short link created by minio-link and the object behind it
+type ListEntry struct {
+	ShortURL     string     `json:"short_url" yaml:"short_url"`
+	MinioURL     string     `json:"minio_url" yaml:"minio_url"`
+	Bucket       string     `json:"bucket,omitempty" yaml:"bucket,omitempty"`
+	Object       string     `json:"object,omitempty" yaml:"object,omitempty"`
+	Exists       bool       `json:"exists" yaml:"exists"`
+	Size         int64      `json:"size" yaml:"size"`
+	Created      time.Time  `json:"created" yaml:"created"`
+	Clicks       int        `json:"clicks" yaml:"clicks"`
+	LastModified *time.Time `json:"last_modified,omitempty" yaml:"last_modified,omitempty"`
+	LinkExpires  *time.Time `json:"link_expires,omitempty" yaml:"link_expires,omitempty"`
+	DeleteAt     *time.Time `json:"delete_at,omitempty" yaml:"delete_at,omitempty"`
+	Lock         *Lock      `json:"lock,omitempty" yaml:"lock,omitempty"`
+	Error        string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// ListResult is the result of the list command
+type ListResult struct {
+	Entries []ListEntry `json:"entries" yaml:"entries"`
+}
+
+func (r *ListResult) Header() []string {
+	return []string{"LINK", "OBJECT", "SIZE", "CLICKS", "LINK EXPIRES", "DELETED", "LOCK"}
+}
+
+func (r *ListResult) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		size := humanize.IBytes(uint64(e.Size))
+		switch {
+		case e.Error != "":
+			size = "ERROR: " + e.Error
+		case !e.Exists:
+			size = "missing"
+		}
+		rows = append(rows, []string{
+			e.ShortURL,
+			e.Object,
+			size,
+			strconv.Itoa(e.Clicks),
+			formatTimeLeft(e.LinkExpires),
+			formatTimeLeft(e.DeleteAt),
+			e.Lock.String(),
+		})
+	}
+	return rows
+}
+
+func (r *ListResult) Plain() []string {
+	lines := make([]string, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		lines = append(lines, e.ShortURL)
+	}
+	return lines
+}
+
+// Lock is the retention and legal hold of an object
+type Lock struct {
+	Mode        string     `json:"mode,omitempty" yaml:"mode,omitempty"`
+	RetainUntil *time.Time `json:"retain_until,omitempty" yaml:"retain_until,omitempty"`
+	LegalHold   bool       `json:"legal_hold" yaml:"legal_hold"`
+}
+
+// NewLock returns nil for objects without retention and legal hold
+func NewLock(mode string, retainUntil time.Time, legalHold bool) *Lock {
+	if mode == "" && retainUntil.IsZero() && !legalHold {
+		return nil
+	}
+	return &Lock{Mode: mode, RetainUntil: TimeOrNil(retainUntil), LegalHold: legalHold}
+}
+
+// String formats the lock for tables, e.g. "compliance in 29d 23h, legal hold"
+func (l *Lock) String() string {
+	if l == nil {
+		return "-"
+	}
+	var parts []string
+	if l.RetainUntil != nil && l.RetainUntil.After(time.Now()) {
+		parts = append(parts, l.Mode+" "+formatTimeLeft(l.RetainUntil))
+	}
+	if l.LegalHold {
+		parts = append(parts, "legal hold")
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ObjectLock is the lock of a single object shown or changed by the retention command
+type ObjectLock struct {
+	Bucket string `json:"bucket" yaml:"bucket"`
+	Object string `json:"object" yaml:"object"`
+	Lock   *Lock  `json:"lock,omitempty" yaml:"lock,omitempty"`
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// RetentionResult is the result of the retention commands
+type RetentionResult struct {
+	Objects []ObjectLock `json:"objects" yaml:"objects"`
+}
+
+func (r *RetentionResult) Header() []string {
+	return []string{"OBJECT", "RETENTION", "RETAIN UNTIL", "LEGAL HOLD"}
+}
+
+func (r *RetentionResult) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Objects))
+	for _, o := range r.Objects {
+		if o.Error != "" {
+			rows = append(rows, []string{o.Object, "ERROR: " + o.Error, "", ""})
+			continue
+		}
+		mode, until, legalHold := "none", "-", "off"
+		if o.Lock != nil {
+			if o.Lock.Mode != "" {
+				mode = o.Lock.Mode
+				until = formatTime(o.Lock.RetainUntil)
+			}
+			if o.Lock.LegalHold {
+				legalHold = "on"
+			}
+		}
+		rows = append(rows, []string{o.Object, mode, until, legalHold})
+	}
+	return rows
+}
+
+func (r *RetentionResult) Plain() []string {
+	lines := make([]string, 0, len(r.Objects))
+	for _, o := range r.Objects {
+		if o.Error == "" {
+			lines = append(lines, o.Bucket+"/"+o.Object+" "+o.Lock.String())
+		}
+	}
+	return lines
+}
+
+// UploadRequestResult is the result of the request-upload command
+type UploadRequestResult struct {
+	Method   string `json:"method" yaml:"method"`
+	Bucket   string `json:"bucket" yaml:"bucket"`
+	Prefix   string `json:"prefix" yaml:"prefix"`
+	Object   string `json:"object,omitempty" yaml:"object,omitempty"`
+	MinioURL string `json:"minio_url" yaml:"minio_url"`
+	ShortURL string `json:"short_url,omitempty" yaml:"short_url,omitempty"`
+	// Headers of PUT requests and form fields of POST requests the uploader has to send
+	Headers     map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	FormData    map[string]string `json:"form_data,omitempty" yaml:"form_data,omitempty"`
+	MaxSize     int64             `json:"max_size,omitempty" yaml:"max_size,omitempty"`
+	ContentType string            `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+	Expires     *time.Time        `json:"expires" yaml:"expires"`
+	// Example curl command for the uploader
+	Command string `json:"command" yaml:"command"`
+}
+
+func (r *UploadRequestResult) Header() []string {
+	return []string{"METHOD", "PREFIX", "MAX SIZE", "CONTENT TYPE", "EXPIRES", "LINK"}
+}
+
+func (r *UploadRequestResult) Rows() [][]string {
+	maxSize := "-"
+	if r.MaxSize > 0 {
+		maxSize = humanize.IBytes(uint64(r.MaxSize))
+	}
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = "any"
+	}
+	return [][]string{{r.Method, r.Prefix, maxSize, contentType, formatTime(r.Expires), r.Share()}}
+}
+
+func (r *UploadRequestResult) Plain() []string {
+	if share := r.Share(); share != r.Command {
+		return []string{share, r.Command}
+	}
+	return []string{r.Command}
+}
+
+// Share returns what has to be sent to the uploader, the link of a PUT request or
+// the whole command of a POST request (its form fields are no part of the link)
+func (r *UploadRequestResult) Share() string {
+	if r.Method == "POST" {
+		return r.Command
+	}
+	if r.ShortURL != "" {
+		return r.ShortURL
+	}
+	return r.MinioURL
+}
+
+// InboxEntry is a file uploaded via a requested link
+type InboxEntry struct {
+	Bucket   string    `json:"bucket" yaml:"bucket"`
+	Object   string    `json:"object" yaml:"object"`
+	Size     int64     `json:"size" yaml:"size"`
+	Uploaded time.Time `json:"uploaded" yaml:"uploaded"`
+	MinioURL string    `json:"minio_url" yaml:"minio_url"`
+	// Set if the file was downloaded
+	Path  string `json:"path,omitempty" yaml:"path,omitempty"`
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// InboxResult is the result of the inbox command
+type InboxResult struct {
+	Entries []InboxEntry `json:"entries" yaml:"entries"`
+}
+
+func (r *InboxResult) Header() []string {
+	return []string{"OBJECT", "SIZE", "UPLOADED", "PATH"}
+}
+
+func (r *InboxResult) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		status := e.Path
+		switch {
+		case e.Error != "":
+			status = "FAILED: " + e.Error
+		case status == "":
+			status = "-"
+		}
+		rows = append(rows, []string{
+			e.Object,
+			humanize.IBytes(uint64(e.Size)),
+			e.Uploaded.Local().Format(timeFormat),
+			status,
+		})
+	}
+	return rows
+}
+
+func (r *InboxResult) Plain() []string {
+	lines := make([]string, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		switch {
+		case e.Error != "":
+		case e.Path != "":
+			lines = append(lines, e.Path)
+		default:
+			lines = append(lines, e.Bucket+"/"+e.Object)
+		}
+	}
+	return lines
+}
+
+// HistoryResult is the result of the history command
+type HistoryResult struct {
+	Entries []history.Entry `json:"entries" yaml:"entries"`
+	Copied  string          `json:"copied,omitempty" yaml:"copied,omitempty"`
+}
+
+func (r *HistoryResult) Header() []string {
+	return []string{"#", "UPLOADED", "FILE", "SIZE", "VISIBILITY", "EXPIRES", "LINK"}
+}
+
+func (r *HistoryResult) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Entries))
+	for i, e := range r.Entries {
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			e.Time.Local().Format(timeFormat),
+			e.FileName,
+			humanize.IBytes(uint64(e.Size)),
+			visibility(e.Public),
+			formatTime(e.Expires),
+			e.Link(),
+		})
+	}
+	return rows
+}
+
+func (r *HistoryResult) Plain() []string {
+	if r.Copied != "" {
+		return []string{r.Copied}
+	}
+	lines := make([]string, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		lines = append(lines, e.Link())
+	}
+	return lines
+}
+
+// Renewal is a single renewed link
+type Renewal struct {
+	Bucket   string     `json:"bucket" yaml:"bucket"`
+	Object   string     `json:"object" yaml:"object"`
+	MinioURL string     `json:"minio_url,omitempty" yaml:"minio_url,omitempty"`
+	ShortURL string     `json:"short_url,omitempty" yaml:"short_url,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+	Error    string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// RenewResult is the result of the renew command
+type RenewResult struct {
+	Renewed []Renewal `json:"renewed" yaml:"renewed"`
+}
+
+func (r *RenewResult) Header() []string {
+	return []string{"OBJECT", "EXPIRES", "LINK"}
+}
+
+func (r *RenewResult) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Renewed))
+	for _, e := range r.Renewed {
+		link := e.ShortURL
+		if e.Error != "" {
+			link = "FAILED: " + e.Error
+		}
+		rows = append(rows, []string{e.Object, formatTime(e.Expires), link})
+	}
+	return rows
+}
+
+func (r *RenewResult) Plain() []string {
+	lines := make([]string, 0, len(r.Renewed))
+	for _, e := range r.Renewed {
+		if e.Error == "" {
+			lines = append(lines, e.ShortURL)
+		}
+	}
+	return lines
+}
+
+// Deletion is a single deleted object and its short link
+type Deletion struct {
+	Bucket   string `json:"bucket" yaml:"bucket"`
+	Object   string `json:"object" yaml:"object"`
+	ShortURL string `json:"short_url,omitempty" yaml:"short_url,omitempty"`
+	Deleted  bool   `json:"deleted" yaml:"deleted"`
+	Error    string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// DeleteResult is the result of the delete command
+type DeleteResult struct {
+	DryRun  bool       `json:"dry_run" yaml:"dry_run"`
+	Deleted []Deletion `json:"deleted" yaml:"deleted"`
+}
+
+func (r *DeleteResult) Header() []string {
+	return []string{"OBJECT", "BUCKET", "LINK", "STATUS"}
+}
+
+func (r *DeleteResult) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Deleted))
+	for _, e := range r.Deleted {
+		status := "deleted"
+		switch {
+		case e.Error != "":
+			status = "FAILED: " + e.Error
+		case r.DryRun:
+			status = "would be deleted"
+		case !e.Deleted:
+			status = "kept"
+		}
+		rows = append(rows, []string{e.Object, e.Bucket, e.ShortURL, status})
+	}
+	return rows
+}
+
+// VerifyResult is the result of the verify command
+type VerifyResult struct {
+	Input  string `json:"input" yaml:"input"`
+	File   string `json:"file,omitempty" yaml:"file,omitempty"`
+	Bucket string `json:"bucket,omitempty" yaml:"bucket,omitempty"`
+	Object string `json:"object,omitempty" yaml:"object,omitempty"`
+	Size   int64  `json:"size" yaml:"size"`
+	// Checksum recorded on upload, empty if there is none
+	Expected string `json:"expected,omitempty" yaml:"expected,omitempty"`
+	Actual   string `json:"actual" yaml:"actual"`
+	// Where the expected checksum came from (metadata or history)
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+	Status string `json:"status" yaml:"status"`
+}
+
+func (r *VerifyResult) Header() []string {
+	return []string{"NAME", "SIZE", "CHECKSUM", "SOURCE", "STATUS"}
+}
+
+func (r *VerifyResult) Rows() [][]string {
+	name := r.Object
+	if r.File != "" {
+		name = r.File
+	}
+	size := humanize.IBytes(uint64(r.Size))
+	return [][]string{{name, size, r.Actual, r.Source, r.Status}}
+}
+
+func (r *VerifyResult) Plain() []string {
+	return []string{r.Status}
+}
+
+// Statuses of a VerifyResult
+const (
+	VerifyOK       string = "ok"
+	VerifyMismatch string = "mismatch"
+	VerifyUnknown  string = "unknown"
+)
+
+// VersionResult is the result of the version command
+type VersionResult struct {
+	Version   string `json:"version" yaml:"version"`
+	Date      string `json:"date" yaml:"date"`
+	GitCommit string `json:"git_commit" yaml:"git_commit"`
+	GoOS      string `json:"go_os" yaml:"go_os"`
+	GoArch    string `json:"go_arch" yaml:"go_arch"`
+	GoVersion string `json:"go_version" yaml:"go_version"`
+}
+
+func (r *VersionResult) Header() []string {
+	return []string{"VERSION", "DATE", "COMMIT", "OS", "ARCH", "GO"}
+}
+
+func (r *VersionResult) Rows() [][]string {
+	return [][]string{{r.Version, r.Date, r.GitCommit, r.GoOS, r.GoArch, r.GoVersion}}
+}
+
+func (r *VersionResult) Plain() []string {
+	return []string{r.Version}
+}
+
+// UpdateResult is the result of the update command
+type UpdateResult struct {
+	CurrentVersion string `json:"current_version" yaml:"current_version"`
+	LatestVersion  string `json:"latest_version" yaml:"latest_version"`
+	Updated        bool   `json:"updated" yaml:"updated"`
+	Changelog      string `json:"changelog,omitempty" yaml:"changelog,omitempty"`
+}
+
+func (r *UpdateResult) Header() []string {
+	return []string{"CURRENT", "LATEST", "STATUS"}
+}
+
+func (r *UpdateResult) Rows() [][]string {
+	status := "up to date"
+	if r.Updated {
+		status = "updated, please restart the app"
+	}
+	rows := [][]string{{r.CurrentVersion, r.LatestVersion, status}}
+	for _, line := range strings.Split(r.Changelog, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			rows = append(rows, []string{"", "", line})
+		}
+	}
+	return rows
+}
+
+// Profile is a single named config profile, secrets are never included
+type Profile struct {
+	Name              string `json:"name" yaml:"name"`
+	Default           bool   `json:"default" yaml:"default"`
+	Active            bool   `json:"active" yaml:"active"`
+	Storage           string `json:"storage" yaml:"storage"`
+	StorageEndpoint   string `json:"storage_endpoint,omitempty" yaml:"storage_endpoint,omitempty"`
+	Bucket            string `json:"bucket" yaml:"bucket"`
+	Shortener         string `json:"shortener" yaml:"shortener"`
+	ShortenerEndpoint string `json:"shortener_endpoint,omitempty" yaml:"shortener_endpoint,omitempty"`
+	Error             string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// ProfilesResult is the result of the config profiles commands
+type ProfilesResult struct {
+	File     string    `json:"file" yaml:"file"`
+	Profiles []Profile `json:"profiles" yaml:"profiles"`
+}
+
+func (r *ProfilesResult) Header() []string {
+	return []string{"", "NAME", "STORAGE", "BUCKET", "SHORTENER"}
+}
+
+func (r *ProfilesResult) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Profiles))
+	for _, p := range r.Profiles {
+		marker := ""
+		if p.Active {
+			marker = "*"
+		}
+		name := p.Name
+		if p.Default {
+			name += " (default)"
+		}
+		if p.Error != "" {
+			rows = append(rows, []string{marker, name, "INVALID: " + p.Error, "", ""})
+			continue
+		}
+		rows = append(rows, []string{
+			marker,
+			name,
+			withEndpoint(p.Storage, p.StorageEndpoint),
+			p.Bucket,
+			withEndpoint(p.Shortener, p.ShortenerEndpoint),
+		})
+	}
+	return rows
+}
+
+func (r *ProfilesResult) Plain() []string {
+	lines := make([]string, 0, len(r.Profiles))
+	for _, p := range r.Profiles {
+		lines = append(lines, p.Name)
+	}
+	return lines
+}
+
+// Statuses of a ConfigCheck
+const (
+	CheckOK      string = "ok"
+	CheckError   string = "error"
+	CheckSkipped string = "skipped"
+)
+
+// ConfigCheck is a single checked config variable or connection
+type ConfigCheck struct {
+	Key     string `json:"key" yaml:"key"`
+	Value   string `json:"value,omitempty" yaml:"value,omitempty"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// ConfigReport is the result of the config validate and config init commands
+type ConfigReport struct {
+	Profile string `json:"profile,omitempty" yaml:"profile,omitempty"`
+	// File the config was written to (config init only)
+	File   string        `json:"file,omitempty" yaml:"file,omitempty"`
+	Valid  bool          `json:"valid" yaml:"valid"`
+	Checks []ConfigCheck `json:"checks" yaml:"checks"`
+}
+
+func (r *ConfigReport) Header() []string {
+	return []string{"KEY", "VALUE", "STATUS", "MESSAGE"}
+}
+
+func (r *ConfigReport) Rows() [][]string {
+	rows := make([][]string, 0, len(r.Checks))
+	for _, c := range r.Checks {
+		rows = append(rows, []string{c.Key, c.Value, strings.ToUpper(c.Status), c.Message})
+	}
+	return rows
+}
+
+func (r *ConfigReport) Plain() []string {
+	var lines []string
+	for _, c := range r.Checks {
+		if c.Status == CheckError {
+			lines = append(lines, fmt.Sprintf("%s: %s", c.Key, c.Message))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "ok")
+	}
+	return lines
+}
+
+// SecretResult is the result of the config set-secret command, never contains the secret
+type SecretResult struct {
+	Name  string `json:"name" yaml:"name"`
+	Store string `json:"store" yaml:"store"`
+	// Value to use for a config variable, e.g. keyring:minio-secret
+	Reference string `json:"reference" yaml:"reference"`
+}
+
+func (r *SecretResult) Header() []string {
+	return []string{"NAME", "STORE", "REFERENCE"}
+}
+
+func (r *SecretResult) Rows() [][]string {
+	return [][]string{{r.Name, r.Store, r.Reference}}
+}
+
+func (r *SecretResult) Plain() []string {
+	return []string{r.Reference}
+}
+
+const (
+	timeFormat string = "2006-01-02 15:04"
+)
+
+func visibility(public bool) string {
+	if public {
+		return "public"
+	}
+	return "private"
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	formatted := t.Local().Format(timeFormat)
+	if t.Before(time.Now()) {
+		formatted += " (expired)"
+	}
+	return formatted
+}
+
+func withEndpoint(backend string, endpoint string) string {
+	if endpoint == "" {
+		return backend
+	}
+	return fmt.Sprintf("%s (%s)", backend, endpoint)
+}
+
+// Formats the time left until t, nil means never
+func formatTimeLeft(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	left := time.Until(*t)
+	if left <= 0 {
+		return "expired"
+	}
+	if left < 24*time.Hour {
+		return "in " + left.Round(time.Minute).String()
+	}
+	days := int(left.Hours() / 24)
+	hours := int(left.Hours()) % 24
+	return fmt.Sprintf("in %dd %dh", days, hours)
+}
+
+// TimeOrNil returns nil for the zero time, used for optional result fields
+func TimeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
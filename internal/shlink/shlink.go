@@ -0,0 +1,302 @@
+package shlink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/shortener"
+	"github.com/devusSs/minio-link/pkg/log"
+)
+
+// Wrapper for Shlink REST API (v3)
+type ShlinkClient struct {
+	logger  *log.Logger
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// ShortenURL shortens a URL via Shlink
+func (c *ShlinkClient) ShortenURL(ctx context.Context, input string) (string, error) {
+	if _, err := url.ParseRequestURI(input); err != nil {
+		return "", fmt.Errorf("invalid input url: %w", err)
+	}
+
+	body := createShortURLRequest{
+		LongURL: input,
+		Title:   defaultUploadTitle,
+		Tags:    []string{defaultTag},
+	}
+	var res shortURL
+	if err := c.do(ctx, http.MethodPost, "/short-urls", nil, body, &res); err != nil {
+		return "", fmt.Errorf("failed to shorten url: %w", err)
+	}
+
+	c.logger.Debug(fmt.Sprintf("shortened url: %s", res.ShortURL))
+
+	return res.ShortURL, nil
+}
+
+// ExpandURL expands a shortened URL via Shlink
+func (c *ShlinkClient) ExpandURL(ctx context.Context, input string) (string, error) {
+	res, err := c.getShortURL(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand url: %w", err)
+	}
+
+	c.logger.Debug(fmt.Sprintf("expanded url: %s", res.LongURL))
+
+	return res.LongURL, nil
+}
+
+// UpdateURL points an existing shortened URL to a new long URL via Shlink
+func (c *ShlinkClient) UpdateURL(ctx context.Context, shortURL string, input string) error {
+	if _, err := url.ParseRequestURI(input); err != nil {
+		return fmt.Errorf("invalid input url: %w", err)
+	}
+	code, query, err := parseShortURL(shortURL)
+	if err != nil {
+		return err
+	}
+
+	body := editShortURLRequest{LongURL: input}
+	err = c.do(ctx, http.MethodPatch, "/short-urls/"+url.PathEscape(code), query, body, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+
+	c.logger.Debug(fmt.Sprintf("updated url: %s -> %s", shortURL, input))
+
+	return nil
+}
+
+// DeleteURL deletes a shortened URL via Shlink
+func (c *ShlinkClient) DeleteURL(ctx context.Context, shortURL string) error {
+	code, query, err := parseShortURL(shortURL)
+	if err != nil {
+		return err
+	}
+
+	err = c.do(ctx, http.MethodDelete, "/short-urls/"+url.PathEscape(code), query, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete url: %w", err)
+	}
+
+	c.logger.Debug(fmt.Sprintf("deleted url: %s", shortURL))
+
+	return nil
+}
+
+// ListURLs gets the shortened urls of this program (tagged with minio-link), newest first
+func (c *ShlinkClient) ListURLs(ctx context.Context, limit int) ([]shortener.Link, error) {
+	if limit < 1 {
+		limit = shortener.DefaultLinkLimit
+		c.logger.Debug(
+			fmt.Sprintf("limit is less than 1, set to default limit: %d", limit),
+		)
+	}
+
+	query := url.Values{}
+	query.Set("tags[]", defaultTag)
+	query.Set("orderBy", "dateCreated-DESC")
+	query.Set("itemsPerPage", strconv.Itoa(limit))
+
+	var res listShortURLsResponse
+	if err := c.do(ctx, http.MethodGet, "/short-urls", query, nil, &res); err != nil {
+		return nil, fmt.Errorf("failed to list urls: %w", err)
+	}
+
+	urls := make([]shortener.Link, 0, len(res.ShortURLs.Data))
+	for _, u := range res.ShortURLs.Data {
+		urls = append(urls, u.toLink())
+	}
+
+	c.logger.Debug(fmt.Sprintf("found %d urls", len(urls)))
+
+	return urls, nil
+}
+
+// Stats gets details and visits of a single shortened URL via Shlink
+func (c *ShlinkClient) Stats(ctx context.Context, input string) (*shortener.Link, error) {
+	res, err := c.getShortURL(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get url stats: %w", err)
+	}
+	link := res.toLink()
+	return &link, nil
+}
+
+// CheckConnection checks endpoint and api key by listing a single url
+func (c *ShlinkClient) CheckConnection(ctx context.Context) (string, error) {
+	if _, err := c.ListURLs(ctx, 1); err != nil {
+		return "", err
+	}
+	return "api key valid", nil
+}
+
+// NewClient creates a new ShlinkClient
+func NewClient(dir string, debug bool, cfg *environment.EnvConfig) *ShlinkClient {
+	return &ShlinkClient{
+		logger: log.NewLogger().
+			WithDirectory(dir).
+			WithName("shlink").
+			WithDebug(debug).
+			WithConsoleOutput(debug),
+		client:  &http.Client{Timeout: 5 * time.Second},
+		baseURL: strings.TrimSuffix(cfg.ShlinkEndpoint, "/"),
+		apiKey:  cfg.ShlinkAPIKey,
+	}
+}
+
+func (c *ShlinkClient) getShortURL(ctx context.Context, input string) (*shortURL, error) {
+	code, query, err := parseShortURL(input)
+	if err != nil {
+		return nil, err
+	}
+	var res shortURL
+	err = c.do(ctx, http.MethodGet, "/short-urls/"+url.PathEscape(code), query, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Sends a JSON request to the api and decodes the JSON response into v if v != nil
+func (c *ShlinkClient) do(
+	ctx context.Context,
+	method string,
+	path string,
+	query url.Values,
+	body any,
+	v any,
+) error {
+	u, err := url.Parse(c.baseURL + defaultAPIPath + path)
+	if err != nil {
+		return fmt.Errorf("invalid base url: %w", err)
+	}
+	u.RawQuery = query.Encode()
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Add("X-Api-Key", c.apiKey)
+	req.Header.Add("Accept", "application/json")
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	c.logger.Debug(fmt.Sprintf("url: %s, method: %s", req.URL.String(), req.Method))
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	c.logger.Debug(fmt.Sprintf("response: %s (%d)", res.Status, res.StatusCode))
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var errRes problemResponse
+		if err := json.Unmarshal(data, &errRes); err != nil || errRes.Detail == "" {
+			return fmt.Errorf("unexpected response: %s", res.Status)
+		}
+		return fmt.Errorf("%s", errRes.Detail)
+	}
+
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return nil
+}
+
+// Splits a short url into its short code and the domain query (for multi domain setups)
+func parseShortURL(input string) (string, url.Values, error) {
+	u, err := url.Parse(input)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid short url: %w", err)
+	}
+	code := strings.Trim(u.Path, "/")
+	if code == "" || strings.Contains(code, "/") {
+		return "", nil, fmt.Errorf("invalid short url: %s", input)
+	}
+	query := url.Values{}
+	if u.Host != "" {
+		query.Set("domain", u.Host)
+	}
+	return code, query, nil
+}
+
+const (
+	defaultAPIPath     string = "/rest/v3"
+	defaultUploadTitle string = "Uploaded using minio-yourls-uploader by devusSs"
+	defaultTag         string = "minio-link"
+)
+
+type createShortURLRequest struct {
+	LongURL string   `json:"longUrl"`
+	Title   string   `json:"title"`
+	Tags    []string `json:"tags"`
+}
+
+type editShortURLRequest struct {
+	LongURL string `json:"longUrl"`
+}
+
+type shortURL struct {
+	ShortCode     string    `json:"shortCode"`
+	ShortURL      string    `json:"shortUrl"`
+	LongURL       string    `json:"longUrl"`
+	DateCreated   time.Time `json:"dateCreated"`
+	Title         string    `json:"title"`
+	Tags          []string  `json:"tags"`
+	VisitsSummary struct {
+		Total int `json:"total"`
+	} `json:"visitsSummary"`
+}
+
+func (u shortURL) toLink() shortener.Link {
+	return shortener.Link{
+		ShortURL: u.ShortURL,
+		URL:      u.LongURL,
+		Created:  u.DateCreated,
+		Clicks:   u.VisitsSummary.Total,
+	}
+}
+
+type listShortURLsResponse struct {
+	ShortURLs struct {
+		Data []shortURL `json:"data"`
+	} `json:"shortUrls"`
+}
+
+type problemResponse struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Status int    `json:"status"`
+}
@@ -0,0 +1,43 @@
+package shortener
+
+import (
+	"context"
+)
+
+// NoneClient does not shorten urls at all, the long url is passed through as is
+type NoneClient struct{}
+
+// ShortenURL returns the input url
+func (c *NoneClient) ShortenURL(ctx context.Context, input string) (string, error) {
+	return input, nil
+}
+
+// ExpandURL returns the input url, it never was shortened
+func (c *NoneClient) ExpandURL(ctx context.Context, shortURL string) (string, error) {
+	return shortURL, nil
+}
+
+// UpdateURL is not supported, there is no short url to point elsewhere
+func (c *NoneClient) UpdateURL(ctx context.Context, shortURL string, input string) error {
+	return ErrUnsupported
+}
+
+// DeleteURL does nothing, there is no short url to delete
+func (c *NoneClient) DeleteURL(ctx context.Context, shortURL string) error {
+	return nil
+}
+
+// ListURLs is not supported, nothing is stored
+func (c *NoneClient) ListURLs(ctx context.Context, limit int) ([]Link, error) {
+	return nil, ErrUnsupported
+}
+
+// Stats is not supported, nothing is tracked
+func (c *NoneClient) Stats(ctx context.Context, shortURL string) (*Link, error) {
+	return nil, ErrUnsupported
+}
+
+// NewNoneClient creates a new passthrough NoneClient
+func NewNoneClient() *NoneClient {
+	return &NoneClient{}
+}
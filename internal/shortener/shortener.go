@@ -0,0 +1,51 @@
+package shortener
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Shortener is implemented by all supported url shortener backends
+type Shortener interface {
+	// ShortenURL shortens the given url and returns the short url
+	ShortenURL(ctx context.Context, input string) (string, error)
+	// ExpandURL returns the long url of a short url
+	ExpandURL(ctx context.Context, shortURL string) (string, error)
+	// UpdateURL points an existing short url to a new long url
+	UpdateURL(ctx context.Context, shortURL string, input string) error
+	// DeleteURL deletes the short url
+	DeleteURL(ctx context.Context, shortURL string) error
+	// ListURLs returns the short urls created by this program, newest first
+	ListURLs(ctx context.Context, limit int) ([]Link, error)
+	// Stats returns details and clicks of a single short url
+	Stats(ctx context.Context, shortURL string) (*Link, error)
+}
+
+// Checker is implemented by backends which can check their connection and credentials
+type Checker interface {
+	// CheckConnection returns a short summary if the backend is usable
+	CheckConnection(ctx context.Context) (string, error)
+}
+
+// Link is a url shortened by this program
+type Link struct {
+	ShortURL string
+	URL      string
+	Created  time.Time
+	Clicks   int
+}
+
+// ErrUnsupported is returned by backends which do not support an action
+var ErrUnsupported = errors.New("action not supported by url shortener")
+
+// Backends which can be chosen via config
+const (
+	BackendYOURLS string = "yourls"
+	BackendShlink string = "shlink"
+	BackendKutt   string = "kutt"
+	BackendNone   string = "none"
+)
+
+// DefaultLinkLimit is used by backends if ListURLs is called with a limit < 1
+const DefaultLinkLimit int = 20
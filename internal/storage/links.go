@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebLinks builds share links for backends whose files are served by a plain web server,
+// private links are signed for the nginx secure_link module:
+//
+//	secure_link $arg_md5,$arg_expires;
+//	secure_link_md5 "$secure_link_expires$uri <secret>";
+type WebLinks struct {
+	// BaseURL the bucket directories are served under
+	BaseURL string
+	// Secret shared with the web server, private links are not possible without it
+	Secret string
+}
+
+// Link returns the public link or a signed link valid for expiry for private objects
+func (l WebLinks) Link(bucket string, object string, public bool, expiry time.Duration) (
+	string,
+	time.Time,
+	error,
+) {
+	u, err := url.Parse(strings.TrimSuffix(l.BaseURL, "/"))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid base url: %w", err)
+	}
+	u.Path = u.Path + "/" + bucket + "/" + object
+	if public {
+		return u.String(), time.Time{}, nil
+	}
+	if l.Secret == "" {
+		return "", time.Time{}, fmt.Errorf("private links require a link secret")
+	}
+	expires := time.Now().Add(expiry)
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	query.Set("md5", l.sign(u.Path, expires))
+	u.RawQuery = query.Encode()
+	return u.String(), expires, nil
+}
+
+// Parse splits a link built by Link into bucket and object name
+func (l WebLinks) Parse(link string) (string, string, error) {
+	base, err := url.Parse(strings.TrimSuffix(l.BaseURL, "/"))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid base url: %w", err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse url: %w", err)
+	}
+	if u.Host != base.Host {
+		return "", "", fmt.Errorf("url %s does not belong to %s", link, l.BaseURL)
+	}
+	rest, ok := strings.CutPrefix(u.Path, base.Path+"/")
+	if !ok {
+		return "", "", fmt.Errorf("url %s does not belong to %s", link, l.BaseURL)
+	}
+	bucket, object, ok := strings.Cut(rest, "/")
+	if !ok || bucket == "" || object == "" {
+		return "", "", fmt.Errorf("invalid url, could not fetch bucket or object name")
+	}
+	return bucket, object, nil
+}
+
+// Expiry reads the expiry of a signed link, public links do not expire
+func (l WebLinks) Expiry(link string) (time.Time, bool) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return time.Time{}, false
+	}
+	seconds, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+func (l WebLinks) sign(uriPath string, expires time.Time) string {
+	sum := md5.Sum([]byte(strconv.FormatInt(expires.Unix(), 10) + uriPath + " " + l.Secret))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/devusSs/minio-link/pkg/progress"
+)
+
+// DownloadResponse saves the body of a plain http response (a file not stored by the
+// configured backend) to customPath, if customPath = "" DefaultDownloadPath of the
+// served file name will be used
+func DownloadResponse(
+	resp *http.Response,
+	customPath string,
+	mode progress.Mode,
+) (*Download, error) {
+	defer resp.Body.Close()
+	name := ResponseName(resp)
+	if customPath == "" {
+		var err error
+		if customPath, err = DefaultDownloadPath(name); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(customPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+	tmpPath := customPath + ".part"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	hash := sha256.New()
+	reporter := progress.New(mode, filepath.Base(customPath), resp.ContentLength)
+	size, err := io.Copy(f, io.TeeReader(resp.Body, io.MultiWriter(hash, reporter)))
+	reporter.Finish()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	if resp.ContentLength >= 0 && size != resp.ContentLength {
+		_ = os.Remove(tmpPath)
+		return nil, fmt.Errorf(
+			"failed to download file: got %d of %d bytes",
+			size,
+			resp.ContentLength,
+		)
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if err := FinishDownload(tmpPath, customPath, "", checksum); err != nil {
+		return nil, err
+	}
+	return &Download{
+		Object:   name,
+		Path:     customPath,
+		Size:     size,
+		Checksum: checksum,
+	}, nil
+}
+
+// StreamResponse streams the body of a plain http response into w
+func StreamResponse(resp *http.Response, w io.Writer, mode progress.Mode) error {
+	defer resp.Body.Close()
+	reporter := progress.New(mode, ResponseName(resp), resp.ContentLength)
+	_, err := io.Copy(w, io.TeeReader(resp.Body, reporter))
+	reporter.Finish()
+	if err != nil {
+		return fmt.Errorf("failed to stream file: %w", err)
+	}
+	return nil
+}
+
+// ResponseName returns the name of the file served by resp, taken from its
+// Content-Disposition or the last element of the url path (random if neither is set)
+func ResponseName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(
+		resp.Header.Get("Content-Disposition"),
+	); err == nil {
+		// Servers choose the name, it must not point anywhere but a plain file
+		if name := params["filename"]; plainFileName(name) {
+			return name
+		}
+	}
+	if resp.Request != nil {
+		if name := path.Base(resp.Request.URL.Path); plainFileName(name) {
+			return name
+		}
+	}
+	return RandomObjectName()
+}
+
+// Checks that name is a single local path element on every OS
+func plainFileName(name string) bool {
+	return !strings.ContainsAny(name, `/\`) && filepath.IsLocal(name)
+}
@@ -0,0 +1,469 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+
+	"github.com/devusSs/minio-link/pkg/progress"
+)
+
+// ObjectStore is implemented by all supported storage backends
+type ObjectStore interface {
+	// SetProgress sets how transfer progress will be reported
+	SetProgress(mode progress.Mode)
+	// UploadFile uploads a file using a random object name, backends may return an
+	// existing object with the same content instead (see UploadOptions.ForceNew)
+	UploadFile(
+		ctx context.Context,
+		filePath string,
+		public bool,
+		opts UploadOptions,
+	) (*Upload, error)
+	// UploadFileAs uploads a file using the given object name (random if empty)
+	UploadFileAs(
+		ctx context.Context,
+		filePath string,
+		objectName string,
+		public bool,
+		opts UploadOptions,
+	) (*Upload, error)
+	// UploadData uploads data using the given object name
+	UploadData(
+		ctx context.Context,
+		data []byte,
+		objectName string,
+		contentType string,
+		public bool,
+		opts UploadOptions,
+	) (*Upload, error)
+	// UploadStream uploads everything read from r, name is only used for the extension
+	UploadStream(
+		ctx context.Context,
+		r io.Reader,
+		name string,
+		public bool,
+		opts UploadOptions,
+	) (*Upload, error)
+	// DownloadFile downloads the object behind a share link, "bucket/key" or a plain
+	// key to customPath, if customPath = "" DefaultDownloadPath will be used
+	DownloadFile(ctx context.Context, input string, customPath string) (*Download, error)
+	// DownloadToWriter streams the object behind a share link or object key into w
+	DownloadToWriter(ctx context.Context, input string, w io.Writer) error
+	// VerifyObject reads the object and compares its content with the checksum
+	// recorded on upload (if the backend stores one)
+	VerifyObject(ctx context.Context, bucket string, object string) (*Verification, error)
+	// StatObjects checks if the objects behind the share links still exist
+	StatObjects(ctx context.Context, links []string) []ObjectStatus
+	// ResolveObject resolves a share link, "bucket/key" or a plain key
+	// (assumed to be private) to bucket and object name
+	ResolveObject(input string) (string, string, error)
+	// DeleteObject removes an object, objects which are already gone are no error
+	DeleteObject(ctx context.Context, bucket string, object string) error
+	// RenewLink creates a fresh expiring (presigned) link for a private object,
+	// if expiry <= 0 the configured default expiry will be used
+	RenewLink(
+		ctx context.Context,
+		bucket string,
+		object string,
+		expiry time.Duration,
+	) (string, time.Time, error)
+	// ListIncompleteUploads lists unfinished uploads which may be resumed
+	ListIncompleteUploads(ctx context.Context) ([]IncompleteUpload, error)
+	// AbortIncompleteUploads aborts unfinished uploads (only the given file's if != "")
+	AbortIncompleteUploads(ctx context.Context, filePath string) (int, error)
+}
+
+// Checker is implemented by backends which can check their connection and credentials
+type Checker interface {
+	// CheckConnection returns a short summary if the backend is usable
+	CheckConnection(ctx context.Context) (string, error)
+}
+
+// Locker is implemented by backends supporting object locks (retention and legal hold)
+type Locker interface {
+	// GetLock returns the retention and legal hold of an object
+	GetLock(ctx context.Context, bucket string, object string) (Lock, error)
+	// SetRetention protects an object from being removed or overwritten until the given time
+	SetRetention(
+		ctx context.Context,
+		bucket string,
+		object string,
+		mode string,
+		until time.Time,
+	) error
+	// ClearRetention removes a governance retention, compliance retentions can not be removed
+	ClearRetention(ctx context.Context, bucket string, object string) error
+	// SetLegalHold enables or disables the legal hold of an object
+	SetLegalHold(ctx context.Context, bucket string, object string, enabled bool) error
+}
+
+// Requester is implemented by backends which can let others upload files without credentials
+type Requester interface {
+	// RequestUpload creates a presigned link (PUT) or policy (POST) to upload files below
+	// InboxPrefix
+	RequestUpload(ctx context.Context, req UploadRequest) (*UploadGrant, error)
+	// ListInbox lists the files uploaded via requested links (only below prefix if != "")
+	ListInbox(ctx context.Context, prefix string) ([]InboxObject, error)
+}
+
+// Upload describes a finished upload
+type Upload struct {
+	FileName    string
+	Bucket      string
+	Object      string
+	Size        int64
+	ContentType string
+	// Hex encoded SHA-256 of the uploaded content
+	Checksum string
+	Public   bool
+	URL      string
+	// Zero for public uploads, their links do not expire
+	Expires time.Time
+	// Zero if the object will not be deleted automatically
+	DeleteAt time.Time
+	// Set if an object with the same content existed and was returned instead
+	Existing bool
+	// Client side encryption scheme of the content, empty if not encrypted
+	Encryption string
+	// Set if the link only works with the customer key (SSE-C) of the object,
+	// such links are neither shortened nor copied to the clipboard
+	KeyRequired bool
+}
+
+// UploadOptions holds optional per upload settings
+type UploadOptions struct {
+	// Deletes the object automatically (if supported by the backend) and
+	// limits private links, zero means no automatic deletion
+	Expires time.Time
+	// Uploads even if an object with the same content exists (only checked by
+	// backends supporting it, for files with random object names)
+	ForceNew bool
+	// Client side encryption scheme of the content, recorded as metadata by backends
+	// supporting it, empty if the content is not encrypted
+	Encryption string
+	// Retention and legal hold of the object (only supported by backends implementing
+	// Locker), zero if the object should not be locked
+	Lock Lock
+	// Length of the content read by UploadStream if known upfront, zero if unknown
+	Size int64
+}
+
+// StreamSize returns the size of the content read by UploadStream, -1 if it is not known
+func (o UploadOptions) StreamSize() int64 {
+	if o.Size > 0 {
+		return o.Size
+	}
+	return -1
+}
+
+// Download describes a finished download
+type Download struct {
+	Bucket string
+	Object string
+	Path   string
+	Size   int64
+	// Hex encoded SHA-256 of the downloaded content
+	Checksum string
+	// Set if the content matched the checksum recorded on upload
+	Verified bool
+	// Client side encryption scheme recorded on upload, empty if unknown or not encrypted
+	Encryption string
+}
+
+// Verification describes an object whose content was checked
+type Verification struct {
+	Bucket string
+	Object string
+	Size   int64
+	// Checksum recorded on upload, empty if the backend does not store one
+	Expected string
+	// Hex encoded SHA-256 of the current content
+	Actual string
+}
+
+// ChecksumError is returned if content does not match the checksum recorded on upload
+type ChecksumError struct {
+	Expected string
+	Actual   string
+	// Path a mismatching download was moved to, empty if no file was kept
+	Quarantined string
+}
+
+func (e *ChecksumError) Error() string {
+	msg := fmt.Sprintf("checksum mismatch: expected %s, got %s", e.Expected, e.Actual)
+	if e.Quarantined != "" {
+		msg += fmt.Sprintf(" (file moved to %s)", e.Quarantined)
+	}
+	return msg
+}
+
+// ObjectStatus describes the object behind a share link
+type ObjectStatus struct {
+	Link         string
+	Bucket       string
+	Object       string
+	Exists       bool
+	Size         int64
+	LastModified time.Time
+	// Zero for public links
+	LinkExpires time.Time
+	// Zero if the object will not be deleted automatically
+	DeleteAt time.Time
+	Lock     Lock
+	Err      error
+}
+
+// Lock describes the retention and legal hold of an object
+type Lock struct {
+	// RetentionGovernance or RetentionCompliance, empty if the object has no retention
+	Mode string
+	// Zero if the object has no retention
+	RetainUntil time.Time
+	LegalHold   bool
+}
+
+// IsZero reports if neither a retention nor a legal hold is set
+func (l Lock) IsZero() bool {
+	return l.Mode == "" && l.RetainUntil.IsZero() && !l.LegalHold
+}
+
+// Retained reports if the retention has not expired yet
+func (l Lock) Retained() bool {
+	return l.RetainUntil.After(time.Now())
+}
+
+// Locked reports if the object can currently not be removed
+func (l Lock) Locked() bool {
+	return l.LegalHold || l.Retained()
+}
+
+// LockedError is returned when removing an object protected by a retention or legal hold
+type LockedError struct {
+	Object string
+	Lock   Lock
+}
+
+func (e *LockedError) Error() string {
+	var reasons []string
+	if e.Lock.Retained() {
+		reasons = append(reasons, fmt.Sprintf(
+			"%s retention until %s",
+			e.Lock.Mode,
+			e.Lock.RetainUntil.Local().Format(time.RFC1123),
+		))
+	}
+	if e.Lock.LegalHold {
+		reasons = append(reasons, "legal hold")
+	}
+	return fmt.Sprintf("object %s is locked (%s)", e.Object, strings.Join(reasons, ", "))
+}
+
+// ParseRetentionMode parses "governance" or "compliance" (case insensitive)
+func ParseRetentionMode(input string) (string, error) {
+	switch mode := strings.ToLower(input); mode {
+	case RetentionGovernance, RetentionCompliance:
+		return mode, nil
+	}
+	return "", fmt.Errorf(
+		"unsupported retention mode %q (supported: %s, %s)",
+		input,
+		RetentionGovernance,
+		RetentionCompliance,
+	)
+}
+
+// UploadRequest describes which files others may upload via a requested link
+type UploadRequest struct {
+	// Uploaded files are stored below InboxPrefix + Prefix
+	Prefix string
+	// Name of the single file which can be uploaded via a PUT link
+	Name string
+	// Creates a POST policy allowing any number of files instead of a PUT link
+	Post bool
+	// Maximum size of a file in bytes (POST only), zero for no limit
+	MaxSize int64
+	// Content type uploaded files must have, empty for any
+	ContentType string
+	// Lifetime of the link, if <= 0 the configured default expiry will be used
+	Expiry time.Duration
+}
+
+// UploadGrant is a presigned link (and form) others can upload files with
+type UploadGrant struct {
+	Bucket string
+	// Keys of uploaded files start with it
+	Prefix string
+	// Key of the file uploaded via a PUT link, empty for POST policies
+	Object string
+	// http.MethodPut or http.MethodPost
+	Method string
+	URL    string
+	// Headers the uploader has to send along with a PUT request
+	Headers map[string]string
+	// Form fields the uploader has to send along with the file of a POST request
+	FormData    map[string]string
+	MaxSize     int64
+	ContentType string
+	Expires     time.Time
+}
+
+// InboxObject describes a file uploaded via a requested link
+type InboxObject struct {
+	Bucket       string
+	Object       string
+	Size         int64
+	LastModified time.Time
+	// Presigned link which can be passed to DownloadFile
+	URL string
+}
+
+// IncompleteUpload describes an unfinished upload
+type IncompleteUpload struct {
+	Bucket    string
+	Object    string
+	UploadID  string
+	Initiated time.Time
+	Public    bool
+	// FilePath is only set if we still have a local journal for the upload
+	FilePath string
+}
+
+// ErrUnsupported is returned by backends which do not support an action
+var ErrUnsupported = errors.New("action not supported by storage backend")
+
+// InboxPrefix is the key prefix of all files uploaded via requested links
+const InboxPrefix string = "inbox/"
+
+// Retention modes of object locks
+const (
+	// RetentionGovernance can be shortened or removed by users with special permissions
+	RetentionGovernance string = "governance"
+	// RetentionCompliance can not be shortened or removed by anyone until it expires
+	RetentionCompliance string = "compliance"
+)
+
+// Backends which can be chosen via config
+const (
+	BackendMinio      string = "minio"
+	BackendFilesystem string = "filesystem"
+	BackendWebDAV     string = "webdav"
+)
+
+// PrivateBucket returns the name of the bucket (or directory) holding private uploads
+func PrivateBucket(bucketName string) string {
+	return bucketName + "-private"
+}
+
+// ResolveKey resolves "bucket/key" or a plain key (assumed to be private) to bucket and
+// object name, links have to be resolved by the backends themselves
+func ResolveKey(input string, bucketName string) (string, string, error) {
+	input = strings.TrimPrefix(input, "/")
+	for _, bucket := range []string{PrivateBucket(bucketName), bucketName} {
+		if key, ok := strings.CutPrefix(input, bucket+"/"); ok && key != "" {
+			return bucket, key, nil
+		}
+	}
+	if input == "" {
+		return "", "", fmt.Errorf("invalid object key")
+	}
+	return PrivateBucket(bucketName), input, nil
+}
+
+// IsLink checks if the input is an http(s) link rather than an object key
+func IsLink(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
+}
+
+// DefaultDownloadPath returns the path downloads are saved to if no path was given
+func DefaultDownloadPath(objectName string) (string, error) {
+	return LocalPath("./files", objectName)
+}
+
+// LocalPath joins the slash separated name (chosen by uploaders or servers) to dir,
+// names which are absolute, contain backslashes or would leave dir are rejected
+func LocalPath(dir string, name string) (string, error) {
+	// Backslashes separate paths on Windows only, they are never part of our names
+	if strings.Contains(name, `\`) || !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", fmt.Errorf("unsafe file name: %q", name)
+	}
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("unsafe file name: %q", name)
+	}
+	return target, nil
+}
+
+// ContentType detects the content type of a file
+func ContentType(filePath string) (string, error) {
+	mime, err := mimetype.DetectFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return mime.String(), nil
+}
+
+// FileChecksum returns the hex encoded SHA-256 of a file
+func FileChecksum(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", fmt.Errorf("failed to compute checksum: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// FinishDownload moves a completely written temporary file to path, if expected is set
+// and does not match the actual checksum the file is quarantined instead
+func FinishDownload(tmpPath string, path string, expected string, actual string) error {
+	if expected != "" && expected != actual {
+		checksumErr := &ChecksumError{Expected: expected, Actual: actual}
+		if err := os.Rename(tmpPath, path+QuarantineSuffix); err != nil {
+			_ = os.Remove(tmpPath)
+			return checksumErr
+		}
+		checksumErr.Quarantined = path + QuarantineSuffix
+		return checksumErr
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to move downloaded file into place: %w", err)
+	}
+	return nil
+}
+
+// QuarantineFile moves a file which failed verification out of the way so it is never
+// mistaken for a good copy, returns the new path
+func QuarantineFile(filePath string) (string, error) {
+	target := filePath + QuarantineSuffix
+	if err := os.Rename(filePath, target); err != nil {
+		return "", fmt.Errorf("failed to quarantine file: %w", err)
+	}
+	return target, nil
+}
+
+// RandomObjectName returns a random object name (without extension)
+func RandomObjectName() string {
+	return uuid.New().String()
+}
+
+const (
+	// SniffLength is the amount of bytes read from streams to detect the content type
+	SniffLength int = 3072
+	// QuarantineSuffix is appended to downloads which failed verification
+	QuarantineSuffix string = ".corrupt"
+)
@@ -0,0 +1,453 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+
+	"github.com/devusSs/minio-link/pkg/log"
+	"github.com/devusSs/minio-link/pkg/progress"
+)
+
+// BlobStore is the minimal set of operations WebStore needs from a backend,
+// keys have the form "bucket/object"
+type BlobStore interface {
+	// Put stores everything read from r, size may be -1 if unknown
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens the blob and returns its size
+	Get(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	// Stat returns an error wrapping fs.ErrNotExist if the blob does not exist
+	Stat(ctx context.Context, key string) (BlobInfo, error)
+	Remove(ctx context.Context, key string) error
+}
+
+// BlobInfo describes a stored blob
+type BlobInfo struct {
+	Size         int64
+	LastModified time.Time
+}
+
+// WebStore implements ObjectStore for backends whose files are served by a web server
+// (e.g. nginx), buckets are plain directories below the served base url
+type WebStore struct {
+	logger     *log.Logger
+	blobs      BlobStore
+	links      WebLinks
+	bucketName string
+	expiry     time.Duration
+	progress   progress.Mode
+}
+
+// CheckConnection checks if the upload directory is accessible
+func (s *WebStore) CheckConnection(ctx context.Context) (string, error) {
+	_, err := s.blobs.Stat(ctx, s.bucketName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Sprintf("directory %s will be created on first upload", s.bucketName), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to access storage: %w", err)
+	}
+	return fmt.Sprintf("directory %s is accessible", s.bucketName), nil
+}
+
+// SetProgress sets how transfer progress will be reported
+func (s *WebStore) SetProgress(mode progress.Mode) {
+	s.progress = mode
+}
+
+// UploadFile uploads a file using a random object name
+func (s *WebStore) UploadFile(
+	ctx context.Context,
+	filePath string,
+	public bool,
+	opts UploadOptions,
+) (*Upload, error) {
+	return s.UploadFileAs(ctx, filePath, "", public, opts)
+}
+
+// UploadFileAs uploads a file using the given object name (random if empty)
+func (s *WebStore) UploadFileAs(
+	ctx context.Context,
+	filePath string,
+	objectName string,
+	public bool,
+	opts UploadOptions,
+) (*Upload, error) {
+	s.logger.Debug(fmt.Sprintf("trying to upload file: %s (public: %t)", filePath, public))
+	if err := s.checkUpload(public, opts); err != nil {
+		return nil, err
+	}
+	contentType, err := ContentType(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mime type: %w", err)
+	}
+	if objectName == "" {
+		objectName = RandomObjectName() + filepath.Ext(filePath)
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	upload := &Upload{
+		FileName:    filepath.Base(filePath),
+		Bucket:      s.bucket(public),
+		Object:      objectName,
+		ContentType: contentType,
+		Public:      public,
+		Encryption:  opts.Encryption,
+	}
+	if err := s.put(ctx, upload, f, stat.Size()); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// UploadData uploads data using the given object name
+func (s *WebStore) UploadData(
+	ctx context.Context,
+	data []byte,
+	objectName string,
+	contentType string,
+	public bool,
+	opts UploadOptions,
+) (*Upload, error) {
+	s.logger.Debug(fmt.Sprintf("trying to upload data: %s (public: %t)", objectName, public))
+	if err := s.checkUpload(public, opts); err != nil {
+		return nil, err
+	}
+	upload := &Upload{
+		FileName:    path.Base(objectName),
+		Bucket:      s.bucket(public),
+		Object:      objectName,
+		ContentType: contentType,
+		Public:      public,
+		Encryption:  opts.Encryption,
+	}
+	if err := s.put(ctx, upload, bytes.NewReader(data), int64(len(data))); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// UploadStream uploads everything read from r, the content type is sniffed
+// from the first bytes and name is only used for the extension
+func (s *WebStore) UploadStream(
+	ctx context.Context,
+	r io.Reader,
+	name string,
+	public bool,
+	opts UploadOptions,
+) (*Upload, error) {
+	s.logger.Debug(fmt.Sprintf("trying to upload stream: %s (public: %t)", name, public))
+	if err := s.checkUpload(public, opts); err != nil {
+		return nil, err
+	}
+	head := make([]byte, SniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	head = head[:n]
+	mime := mimetype.Detect(head)
+	ext := filepath.Ext(name)
+	if ext == "" {
+		ext = mime.Extension()
+	}
+	objectName := RandomObjectName() + ext
+	if name == "" {
+		name = objectName
+	}
+	upload := &Upload{
+		FileName:    name,
+		Bucket:      s.bucket(public),
+		Object:      objectName,
+		ContentType: mime.String(),
+		Public:      public,
+		Encryption:  opts.Encryption,
+	}
+	err = s.put(ctx, upload, io.MultiReader(bytes.NewReader(head), r), opts.StreamSize())
+	if err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// DownloadFile downloads the object behind a share link or object key to customPath
+func (s *WebStore) DownloadFile(
+	ctx context.Context,
+	input string,
+	customPath string,
+) (*Download, error) {
+	s.logger.Debug(fmt.Sprintf("trying to download file: %s", input))
+	bucket, object, err := s.ResolveObject(input)
+	if err != nil {
+		return nil, err
+	}
+	if customPath == "" {
+		customPath, err = DefaultDownloadPath(object)
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Debug(fmt.Sprintf("custom path not provided, using default: %s", customPath))
+	}
+	rc, size, err := s.blobs.Get(ctx, bucket+"/"+object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	defer rc.Close()
+	if err := os.MkdirAll(filepath.Dir(customPath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+	tmpPath := customPath + ".part"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	hash := sha256.New()
+	reporter := progress.New(s.progress, filepath.Base(customPath), size)
+	_, err = io.Copy(f, io.TeeReader(rc, io.MultiWriter(hash, reporter)))
+	reporter.Finish()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	// Checksums are not stored next to the files, callers may verify against the history
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if err := FinishDownload(tmpPath, customPath, "", checksum); err != nil {
+		return nil, err
+	}
+	return &Download{
+		Bucket:   bucket,
+		Object:   object,
+		Path:     customPath,
+		Size:     size,
+		Checksum: checksum,
+	}, nil
+}
+
+// DownloadToWriter streams the object behind a share link or object key into w
+func (s *WebStore) DownloadToWriter(ctx context.Context, input string, w io.Writer) error {
+	s.logger.Debug(fmt.Sprintf("trying to stream file: %s", input))
+	bucket, object, err := s.ResolveObject(input)
+	if err != nil {
+		return err
+	}
+	rc, size, err := s.blobs.Get(ctx, bucket+"/"+object)
+	if err != nil {
+		return fmt.Errorf("failed to get object: %w", err)
+	}
+	defer rc.Close()
+	reporter := progress.New(s.progress, object, size)
+	_, err = io.Copy(w, io.TeeReader(rc, reporter))
+	reporter.Finish()
+	if err != nil {
+		return fmt.Errorf("failed to stream file: %w", err)
+	}
+	return nil
+}
+
+// VerifyObject reads the object and returns its checksum, checksums are not recorded
+// on upload so Expected is always empty
+func (s *WebStore) VerifyObject(
+	ctx context.Context,
+	bucket string,
+	object string,
+) (*Verification, error) {
+	rc, size, err := s.blobs.Get(ctx, bucket+"/"+object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+	defer rc.Close()
+	hash := sha256.New()
+	reporter := progress.New(s.progress, object, size)
+	_, err = io.Copy(hash, io.TeeReader(rc, reporter))
+	reporter.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	return &Verification{
+		Bucket: bucket,
+		Object: object,
+		Size:   size,
+		Actual: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// StatObjects checks if the objects behind the share links still exist
+func (s *WebStore) StatObjects(ctx context.Context, links []string) []ObjectStatus {
+	statuses := make([]ObjectStatus, 0, len(links))
+	for _, link := range links {
+		status := ObjectStatus{Link: link}
+		status.Bucket, status.Object, status.Err = s.links.Parse(link)
+		if status.Err != nil {
+			statuses = append(statuses, status)
+			continue
+		}
+		status.LinkExpires, _ = s.links.Expiry(link)
+		info, err := s.blobs.Stat(ctx, status.Bucket+"/"+status.Object)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				status.Err = fmt.Errorf("failed to get object: %w", err)
+			}
+			statuses = append(statuses, status)
+			continue
+		}
+		status.Exists = true
+		status.Size = info.Size
+		status.LastModified = info.LastModified
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// ResolveObject resolves a share link, "bucket/key" or a plain key to bucket and object name
+func (s *WebStore) ResolveObject(input string) (string, string, error) {
+	if IsLink(input) {
+		return s.links.Parse(input)
+	}
+	return ResolveKey(input, s.bucketName)
+}
+
+// DeleteObject removes an object, objects which are already gone are no error
+func (s *WebStore) DeleteObject(ctx context.Context, bucket string, object string) error {
+	err := s.blobs.Remove(ctx, bucket+"/"+object)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.logger.Debug(fmt.Sprintf("object %s/%s is already gone", bucket, object))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove object: %w", err)
+	}
+	s.logger.Debug(fmt.Sprintf("removed object %s/%s", bucket, object))
+	return nil
+}
+
+// RenewLink creates a freshly signed link for a private object
+func (s *WebStore) RenewLink(
+	ctx context.Context,
+	bucket string,
+	object string,
+	expiry time.Duration,
+) (string, time.Time, error) {
+	if bucket == s.bucket(true) {
+		return "", time.Time{}, fmt.Errorf("object %s is public, link does not expire", object)
+	}
+	if expiry <= 0 {
+		expiry = s.expiry
+	}
+	if _, err := s.blobs.Stat(ctx, bucket+"/"+object); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to stat object: %w", err)
+	}
+	link, expires, err := s.links.Link(bucket, object, false, expiry)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	s.logger.Debug(fmt.Sprintf("renewed link for %s/%s: %s", bucket, object, link))
+	return link, expires, nil
+}
+
+// ListIncompleteUploads is not supported, uploads are not split into resumable parts
+func (s *WebStore) ListIncompleteUploads(ctx context.Context) ([]IncompleteUpload, error) {
+	return nil, fmt.Errorf("resumable uploads: %w", ErrUnsupported)
+}
+
+// AbortIncompleteUploads is not supported, uploads are not split into resumable parts
+func (s *WebStore) AbortIncompleteUploads(ctx context.Context, filePath string) (int, error) {
+	return 0, fmt.Errorf("resumable uploads: %w", ErrUnsupported)
+}
+
+// Fails early for uploads we could not share afterwards
+func (s *WebStore) checkUpload(public bool, opts UploadOptions) error {
+	if !opts.Expires.IsZero() {
+		return fmt.Errorf("automatic deletion of uploads: %w", ErrUnsupported)
+	}
+	if !opts.Lock.IsZero() {
+		return fmt.Errorf("object locks: %w", ErrUnsupported)
+	}
+	if !public && s.links.Secret == "" {
+		return fmt.Errorf("private uploads require a link secret to sign links")
+	}
+	return nil
+}
+
+// Stores the upload's content and fills in size, checksum and share link
+func (s *WebStore) put(ctx context.Context, upload *Upload, r io.Reader, size int64) error {
+	hash := sha256.New()
+	counter := &CountingWriter{}
+	reporter := progress.New(s.progress, upload.FileName, size)
+	err := s.blobs.Put(
+		ctx,
+		upload.Bucket+"/"+upload.Object,
+		io.TeeReader(r, io.MultiWriter(hash, counter, reporter)),
+		size,
+	)
+	reporter.Finish()
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	upload.Size = counter.N
+	upload.Checksum = hex.EncodeToString(hash.Sum(nil))
+	link, expires, err := s.links.Link(upload.Bucket, upload.Object, upload.Public, s.expiry)
+	if err != nil {
+		return fmt.Errorf("failed to get share link: %w", err)
+	}
+	upload.URL = link
+	upload.Expires = expires
+	s.logger.Debug(fmt.Sprintf("share link: %s", link))
+	return nil
+}
+
+// Returns the bucket (directory) name for public or private uploads
+func (s *WebStore) bucket(public bool) string {
+	if !public {
+		return PrivateBucket(s.bucketName)
+	}
+	return s.bucketName
+}
+
+// NewWebStore creates a new WebStore on top of the given blob store
+func NewWebStore(
+	logger *log.Logger,
+	blobs BlobStore,
+	links WebLinks,
+	bucketName string,
+	expiry time.Duration,
+) *WebStore {
+	return &WebStore{
+		logger:     logger,
+		blobs:      blobs,
+		links:      links,
+		bucketName: bucketName,
+		expiry:     expiry,
+		progress:   progress.ModeNone,
+	}
+}
+
+// CountingWriter counts the bytes written to it
+type CountingWriter struct {
+	N int64
+}
+
+func (w *CountingWriter) Write(p []byte) (int, error) {
+	w.N += int64(len(p))
+	return len(p), nil
+}
@@ -94,25 +94,33 @@ type githubRelease struct {
 	Body       string `json:"body"`
 }
 
+// Update describes the outcome of an update check
+type Update struct {
+	CurrentVersion string
+	LatestVersion  string
+	Applied        bool
+	Changelog      string
+}
+
 // Checks for updates and applies them if possible
-func CheckForUpdatesAndApply(buildVersion string) error {
+func CheckForUpdatesAndApply(buildVersion string) (*Update, error) {
 	updateURL, newVersion, changelog, err := findLatestReleaseURL()
 	if err != nil {
-		return fmt.Errorf("failed to find latest release: %w", err)
+		return nil, fmt.Errorf("failed to find latest release: %w", err)
 	}
 	newVersionAvailable, err := newerVersionAvailable(newVersion, buildVersion)
 	if err != nil {
-		return fmt.Errorf("failed to compare versions: %w", err)
+		return nil, fmt.Errorf("failed to compare versions: %w", err)
 	}
+	update := &Update{CurrentVersion: buildVersion, LatestVersion: newVersion}
 	if newVersionAvailable {
 		if err := doUpdate(updateURL); err != nil {
-			return fmt.Errorf("failed to update: %w", err)
+			return nil, fmt.Errorf("failed to update: %w", err)
 		}
-		fmt.Printf("Update changelog (%s): %s\n", newVersion, changelog)
-		fmt.Println("Update succeeded, please restart the app")
-		os.Exit(0)
+		update.Applied = true
+		update.Changelog = changelog
 	}
-	return nil
+	return update, nil
 }
 
 // Queries the latest release from Github repo.
@@ -142,14 +150,8 @@ func findLatestReleaseURL() (string, string, string, error) {
 	for _, asset := range release.Assets {
 		releaseName := strings.ToLower(asset.Name)
 		if strings.Contains(releaseName, buildArch) && strings.Contains(releaseName, buildOS) {
-			changeSplit := strings.Split(
-				strings.ReplaceAll(strings.TrimSpace(release.Body), "## Changelog", ""),
-				"\n",
-			)
-			for i, line := range changeSplit {
-				changeSplit[i] = strings.ReplaceAll(fmt.Sprintf("\t\t\t%s", line), "*", "-")
-			}
-			changelog := strings.Join(changeSplit, "\n")
+			changelog := strings.ReplaceAll(release.Body, "## Changelog", "")
+			changelog = strings.ReplaceAll(strings.TrimSpace(changelog), "*", "-")
 			return asset.BrowserDownloadURL, release.TagName, changelog, nil
 		}
 	}
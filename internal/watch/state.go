@@ -0,0 +1,115 @@
+package watch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/devusSs/minio-link/pkg/system"
+)
+
+// Processed is a file which has already been uploaded
+type Processed struct {
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+	Time     time.Time `json:"time"`
+	ShortURL string    `json:"short_url,omitempty"`
+}
+
+// State records processed files of a watched directory so a restart
+// does not upload them again
+type State struct {
+	mu    sync.Mutex
+	path  string
+	files map[string]Processed
+}
+
+// Path returns the path of the state file
+func (s *State) Path() string {
+	return s.path
+}
+
+// Done checks if the file has been processed, files changed afterwards are not
+func (s *State) Done(path string, info os.FileInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.files[path]
+	return ok && p.Size == info.Size() && p.ModTime.Equal(info.ModTime())
+}
+
+// Add records the file as processed and persists the state
+func (s *State) Add(path string, info os.FileInfo, shortURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path] = Processed{
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		Time:     time.Now(),
+		ShortURL: shortURL,
+	}
+	return s.save()
+}
+
+func (s *State) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create watch state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s.files, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal watch state: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	return nil
+}
+
+// LoadState loads the state of the watched directory from the user's data directory,
+// every directory gets its own file
+func LoadState(dir string) (*State, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	dataDir, err := system.GetDataDir()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(abs))
+	s := &State{
+		path: filepath.Join(
+			dataDir,
+			defaultDirectory,
+			stateDirectory,
+			hex.EncodeToString(sum[:8])+".json",
+		),
+		files: make(map[string]Processed),
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.files); err != nil {
+		return nil, fmt.Errorf("failed to parse watch state: %w", err)
+	}
+	return s, nil
+}
+
+const (
+	defaultDirectory string = "minio-link"
+	stateDirectory   string = "watch"
+)
@@ -0,0 +1,331 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/devusSs/minio-link/pkg/log"
+)
+
+// Options holds the settings of a Watcher
+type Options struct {
+	// Also watches sub directories (including ones created later)
+	Recursive bool
+	// Glob patterns matched against the file name and the path relative to the
+	// watched directory, empty means every file
+	Include []string
+	// Glob patterns of files which are never reported, wins over Include
+	Exclude []string
+	// Time a file's size and modification time have to stay the same before
+	// it is considered completely written
+	Settle time.Duration
+}
+
+// Watcher reports new or changed files in a directory once they are completely written
+type Watcher struct {
+	dir    string
+	opts   Options
+	logger *log.Logger
+	fs     *fsnotify.Watcher
+
+	mu      sync.Mutex
+	pending map[string]*pendingFile
+	ready   chan string
+	done    chan struct{}
+}
+
+// A file which is still being written (or was not checked yet)
+type pendingFile struct {
+	timer *time.Timer
+	// Zero until the first check
+	size    int64
+	modTime time.Time
+}
+
+// New starts watching the directory, call Run to receive files and Close when done
+func New(dir string, opts Options, logger *log.Logger) (*Watcher, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat watch directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	for _, pattern := range slices.Concat(opts.Include, opts.Exclude) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+	if opts.Settle <= 0 {
+		opts.Settle = DefaultSettle
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+	w := &Watcher{
+		dir:     filepath.Clean(dir),
+		opts:    opts,
+		logger:  logger,
+		fs:      fsWatcher,
+		pending: make(map[string]*pendingFile),
+		ready:   make(chan string, readyBuffer),
+		done:    make(chan struct{}),
+	}
+	if err := w.add(w.dir); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Existing returns all matching files which are already in the directory
+func (w *Watcher) Existing() ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(w.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != w.dir && (!w.opts.Recursive || w.excluded(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && w.Matches(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk watch directory: %w", err)
+	}
+	return files, nil
+}
+
+// Matches checks the include and exclude patterns for a file in the watched directory
+func (w *Watcher) Matches(path string) bool {
+	if w.excluded(path) {
+		return false
+	}
+	if len(w.opts.Include) == 0 {
+		return true
+	}
+	return w.match(w.opts.Include, path)
+}
+
+// Run calls handle for every completely written file until ctx is done or the watcher
+// is closed, handle is called from a single goroutine so files are processed one by one
+func (w *Watcher) Run(ctx context.Context, handle func(path string)) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case path := <-w.ready:
+				handle(path)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			// Errors (like overflows) lose events but the watcher keeps working
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				w.logger.Warn(fmt.Sprintf("missed file events: %s", err))
+				continue
+			}
+			w.logger.Warn(fmt.Sprintf("failed to watch directory: %s", err))
+		}
+	}
+}
+
+// Close stops watching and drops pending files
+func (w *Watcher) Close() error {
+	close(w.done)
+	w.mu.Lock()
+	for path, p := range w.pending {
+		p.timer.Stop()
+		delete(w.pending, path)
+	}
+	w.mu.Unlock()
+	return w.fs.Close()
+}
+
+func (w *Watcher) handleEvent(event fsnotify.Event) {
+	w.logger.Debug(fmt.Sprintf("got file event: %s", event.String()))
+	switch {
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		w.forget(event.Name)
+	case event.Has(fsnotify.Create), event.Has(fsnotify.Write):
+		info, err := os.Stat(event.Name)
+		if err != nil {
+			return
+		}
+		if info.IsDir() {
+			if event.Has(fsnotify.Create) && w.opts.Recursive && !w.excluded(event.Name) {
+				if err := w.add(event.Name); err != nil {
+					w.logger.Warn(err.Error())
+				}
+				w.scheduleExisting(event.Name)
+			}
+			return
+		}
+		if info.Mode().IsRegular() && w.Matches(event.Name) {
+			w.schedule(event.Name)
+		}
+	}
+}
+
+// Files may have been created in a new directory before it was watched
+func (w *Watcher) scheduleExisting(dir string) {
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir {
+				if w.excluded(path) {
+					return filepath.SkipDir
+				}
+				if err := w.add(path); err != nil {
+					w.logger.Warn(err.Error())
+				}
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && w.Matches(path) {
+			w.schedule(path)
+		}
+		return nil
+	})
+}
+
+// Starts (or restarts) the settle timer of a file
+func (w *Watcher) schedule(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if p, ok := w.pending[path]; ok {
+		p.timer.Reset(w.opts.Settle)
+		return
+	}
+	w.pending[path] = &pendingFile{
+		timer: time.AfterFunc(w.opts.Settle, func() { w.check(path) }),
+	}
+}
+
+// Reports the file if it did not change since the last check, else checks again later
+func (w *Watcher) check(path string) {
+	w.mu.Lock()
+	p, ok := w.pending[path]
+	if !ok {
+		w.mu.Unlock()
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		delete(w.pending, path)
+		w.mu.Unlock()
+		return
+	}
+	if p.modTime.IsZero() || info.Size() != p.size || !info.ModTime().Equal(p.modTime) {
+		p.size = info.Size()
+		p.modTime = info.ModTime()
+		p.timer.Reset(w.opts.Settle)
+		w.mu.Unlock()
+		return
+	}
+	delete(w.pending, path)
+	w.mu.Unlock()
+
+	select {
+	case w.ready <- path:
+	case <-w.done:
+	}
+}
+
+func (w *Watcher) forget(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if p, ok := w.pending[path]; ok {
+		p.timer.Stop()
+		delete(w.pending, path)
+	}
+}
+
+func (w *Watcher) add(dir string) error {
+	if err := w.fs.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	w.logger.Debug(fmt.Sprintf("watching directory: %s", dir))
+	if !w.opts.Recursive || dir != w.dir {
+		return nil
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == dir {
+			return err
+		}
+		if w.excluded(path) {
+			return filepath.SkipDir
+		}
+		if err := w.fs.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		w.logger.Debug(fmt.Sprintf("watching directory: %s", path))
+		return nil
+	})
+}
+
+func (w *Watcher) excluded(path string) bool {
+	return w.match(w.opts.Exclude, path)
+}
+
+// Matches the patterns against the base name and the slash separated relative path
+func (w *Watcher) match(patterns []string, path string) bool {
+	name := filepath.Base(path)
+	rel, err := filepath.Rel(w.dir, path)
+	if err != nil {
+		rel = name
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// DefaultSettle is used if Options.Settle is not set
+	DefaultSettle time.Duration = 2 * time.Second
+	readyBuffer   int           = 64
+)
@@ -0,0 +1,194 @@
+package webdav
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/storage"
+	"github.com/devusSs/minio-link/pkg/log"
+)
+
+// Stores uploads on a WebDAV server, the files are shared via a (separate) base url
+type blobStore struct {
+	logger   *log.Logger
+	client   *http.Client
+	endpoint string
+	username string
+	password string
+
+	// Collections already created by mkdirAll
+	dirsMu sync.Mutex
+	dirs   map[string]bool
+}
+
+func (b *blobStore) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if err := b.mkdirAll(ctx, path.Dir(key)); err != nil {
+		return err
+	}
+	req, err := b.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	res, err := b.do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (b *blobStore) Get(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	req, err := b.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	res, err := b.do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.Body, res.ContentLength, nil
+}
+
+func (b *blobStore) Stat(ctx context.Context, key string) (storage.BlobInfo, error) {
+	req, err := b.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return storage.BlobInfo{}, err
+	}
+	res, err := b.do(req)
+	if err != nil {
+		return storage.BlobInfo{}, err
+	}
+	res.Body.Close()
+	modified, _ := http.ParseTime(res.Header.Get("Last-Modified"))
+	return storage.BlobInfo{Size: res.ContentLength, LastModified: modified}, nil
+}
+
+func (b *blobStore) Remove(ctx context.Context, key string) error {
+	req, err := b.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	res, err := b.do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// Creates all missing collections of the given directory
+func (b *blobStore) mkdirAll(ctx context.Context, dir string) error {
+	b.dirsMu.Lock()
+	defer b.dirsMu.Unlock()
+	current := ""
+	for _, part := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		current += part + "/"
+		if b.dirs[current] {
+			continue
+		}
+		req, err := b.newRequest(ctx, "MKCOL", current, nil)
+		if err != nil {
+			return err
+		}
+		res, err := b.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		res.Body.Close()
+		// 405 means the collection already exists
+		if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("failed to create collection %s: %s", current, res.Status)
+		}
+		b.dirs[current] = true
+	}
+	return nil
+}
+
+func (b *blobStore) newRequest(
+	ctx context.Context,
+	method string,
+	key string,
+	body io.Reader,
+) (*http.Request, error) {
+	u, err := url.Parse(b.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if b.username != "" {
+		req.SetBasicAuth(b.username, b.password)
+	}
+	return req, nil
+}
+
+// Sends the request, non 2xx responses are returned as error (404 wraps fs.ErrNotExist)
+func (b *blobStore) do(req *http.Request) (*http.Response, error) {
+	b.logger.Debug(fmt.Sprintf("url: %s, method: %s", req.URL.String(), req.Method))
+	res, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	b.logger.Debug(fmt.Sprintf("response: %s (%d)", res.Status, res.StatusCode))
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, fmt.Errorf("%s: %w", req.URL.Path, fs.ErrNotExist)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected response: %s", res.Status)
+	}
+	return res, nil
+}
+
+// NewClient creates a new object store using a WebDAV server
+func NewClient(dir string, debug bool, cfg *environment.EnvConfig) (*storage.WebStore, error) {
+	if cfg.WebDAVEndpoint == "" {
+		return nil, fmt.Errorf("LINK_WEBDAV_ENDPOINT is required for the webdav storage")
+	}
+	baseURL := cfg.WebDAVBaseURL
+	if baseURL == "" {
+		baseURL = cfg.WebDAVEndpoint
+	}
+	logger := log.NewLogger().
+		WithDirectory(dir).
+		WithName("webdav").
+		WithDebug(debug).
+		WithConsoleOutput(debug)
+	// No overall timeout, uploads of big files may take a while
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = time.Minute
+	blobs := &blobStore{
+		logger:   logger,
+		client:   &http.Client{Transport: transport},
+		endpoint: cfg.WebDAVEndpoint,
+		username: cfg.WebDAVUsername,
+		password: cfg.WebDAVPassword,
+		dirs:     make(map[string]bool),
+	}
+	return storage.NewWebStore(
+		logger,
+		blobs,
+		storage.WebLinks{BaseURL: baseURL, Secret: cfg.WebDAVLinkSecret},
+		cfg.MinioBucketName,
+		cfg.MinioDefaultExpiry,
+	), nil
+}
@@ -2,16 +2,20 @@ package yourls
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/devusSs/minio-link/internal/config/environment"
+	"github.com/devusSs/minio-link/internal/shortener"
 	"github.com/devusSs/minio-link/pkg/log"
 	"github.com/google/uuid"
 )
@@ -21,7 +25,10 @@ type YOURLSClient struct {
 	logger    *log.Logger
 	client    *http.Client
 	baseURL   string
+	auth      string
 	signature string
+	username  string
+	password  string
 }
 
 // ShortenURL shortens a URL via YOURLS
@@ -38,7 +45,7 @@ func (c *YOURLSClient) ShortenURL(ctx context.Context, input string) (string, er
 	c.logger.Debug(fmt.Sprintf("(base) upload url: %s", u.String()))
 
 	v := make(map[string]string)
-	v["signature"] = c.signature
+	c.authenticate(v)
 	v["action"] = "shorturl"
 	v["format"] = "json"
 	v["url"] = input
@@ -50,7 +57,7 @@ func (c *YOURLSClient) ShortenURL(ctx context.Context, input string) (string, er
 		return "", fmt.Errorf("failed to build request: %w", err)
 	}
 	c.logger.Debug(
-		fmt.Sprintf("url: %s, method: %s, body: %s", req.URL.String(), req.Method, req.Body),
+		fmt.Sprintf("url: %s, method: %s, action: %s", req.URL.String(), req.Method, v["action"]),
 	)
 
 	res, err := c.client.Do(req)
@@ -93,7 +100,7 @@ func (c *YOURLSClient) ExpandURL(ctx context.Context, input string) (string, err
 	c.logger.Debug(fmt.Sprintf("(base) upload url: %s", u.String()))
 
 	v := make(map[string]string)
-	v["signature"] = c.signature
+	c.authenticate(v)
 	v["action"] = "expand"
 	v["format"] = "json"
 	v["shorturl"] = input
@@ -103,7 +110,7 @@ func (c *YOURLSClient) ExpandURL(ctx context.Context, input string) (string, err
 		return "", fmt.Errorf("failed to build request: %w", err)
 	}
 	c.logger.Debug(
-		fmt.Sprintf("url: %s, method: %s, body: %s", req.URL.String(), req.Method, req.Body),
+		fmt.Sprintf("url: %s, method: %s, action: %s", req.URL.String(), req.Method, v["action"]),
 	)
 
 	res, err := c.client.Do(req)
@@ -132,8 +139,106 @@ func (c *YOURLSClient) ExpandURL(ctx context.Context, input string) (string, err
 	return expandRes.Longurl, nil
 }
 
-// Gets the shortened and saved urls of this program via stats endpoint of YOURLS api endpoint
-func (c *YOURLSClient) GetSavedURLs(ctx context.Context, limit int) (map[string]string, error) {
+// UpdateURL points an existing shortened URL to a new long URL via YOURLS,
+// requires the (common) API edit url plugin providing the "update" action
+func (c *YOURLSClient) UpdateURL(ctx context.Context, shortURL string, input string) error {
+	_, err := checkURL(input)
+	if err != nil {
+		return fmt.Errorf("invalid input url: %w", err)
+	}
+
+	u, err := checkURL(fmt.Sprintf("%s/%s", c.baseURL, defaultAPIEndpoint))
+	if err != nil {
+		return fmt.Errorf("invalid base url: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("(base) update url: %s", u.String()))
+
+	v := make(map[string]string)
+	c.authenticate(v)
+	v["action"] = "update"
+	v["format"] = "json"
+	v["shorturl"] = shortURL
+	v["url"] = input
+	v["title"] = defaultUploadTitle
+
+	req, err := buildRequestWithContext(ctx, http.MethodPost, u.String(), createPostRequestBody(v))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	c.logger.Debug(
+		fmt.Sprintf("url: %s, method: %s, action: %s", req.URL.String(), req.Method, v["action"]),
+	)
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	c.logger.Debug(fmt.Sprintf("response: %s (%d)", res.Status, res.StatusCode))
+
+	var updateRes pluginActionResponse
+	if err := unmarshalResponseToJSON(res, &updateRes); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(updateRes.Message, "success") {
+		return fmt.Errorf("failed to update url: %s", updateRes.Message)
+	}
+
+	c.logger.Debug(fmt.Sprintf("updated url: %s -> %s", shortURL, input))
+
+	return nil
+}
+
+// DeleteURL deletes a shortened URL (keyword) via YOURLS,
+// requires the (common) API delete plugin providing the "delete" action
+func (c *YOURLSClient) DeleteURL(ctx context.Context, shortURL string) error {
+	u, err := checkURL(fmt.Sprintf("%s/%s", c.baseURL, defaultAPIEndpoint))
+	if err != nil {
+		return fmt.Errorf("invalid base url: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("(base) delete url: %s", u.String()))
+
+	v := make(map[string]string)
+	c.authenticate(v)
+	v["action"] = "delete"
+	v["format"] = "json"
+	v["shorturl"] = shortURL
+
+	req, err := buildRequestWithContext(ctx, http.MethodPost, u.String(), createPostRequestBody(v))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	c.logger.Debug(
+		fmt.Sprintf("url: %s, method: %s, action: %s", req.URL.String(), req.Method, v["action"]),
+	)
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	c.logger.Debug(fmt.Sprintf("response: %s (%d)", res.Status, res.StatusCode))
+
+	var deleteRes pluginActionResponse
+	if err := unmarshalResponseToJSON(res, &deleteRes); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(deleteRes.Message, "success") {
+		return fmt.Errorf("failed to delete url: %s", deleteRes.Message)
+	}
+
+	c.logger.Debug(fmt.Sprintf("deleted url: %s", shortURL))
+
+	return nil
+}
+
+// ListURLs gets the shortened and saved urls of this program via stats endpoint
+// of YOURLS api endpoint, newest first
+func (c *YOURLSClient) ListURLs(ctx context.Context, limit int) ([]shortener.Link, error) {
 	u, err := checkURL(fmt.Sprintf("%s/%s", c.baseURL, defaultAPIEndpoint))
 	if err != nil {
 		return nil, fmt.Errorf("invalid base url: %w", err)
@@ -148,7 +253,7 @@ func (c *YOURLSClient) GetSavedURLs(ctx context.Context, limit int) (map[string]
 	}
 
 	v := make(map[string]string)
-	v["signature"] = c.signature
+	c.authenticate(v)
 	v["action"] = "stats"
 	v["format"] = "json"
 	v["filter"] = "last"
@@ -159,7 +264,7 @@ func (c *YOURLSClient) GetSavedURLs(ctx context.Context, limit int) (map[string]
 		return nil, fmt.Errorf("failed to build request: %w", err)
 	}
 	c.logger.Debug(
-		fmt.Sprintf("url: %s, method: %s, body: %s", req.URL.String(), req.Method, req.Body),
+		fmt.Sprintf("url: %s, method: %s, action: %s", req.URL.String(), req.Method, v["action"]),
 	)
 
 	res, err := c.client.Do(req)
@@ -184,18 +289,105 @@ func (c *YOURLSClient) GetSavedURLs(ctx context.Context, limit int) (map[string]
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	urls := make(map[string]string)
+	urls := make([]shortener.Link, 0, len(data.Links))
 	for _, link := range data.Links {
 		if strings.Contains(link.Title, defaultUploadTitle) {
-			urls[link.ShortURL] = link.URL
+			urls = append(urls, link.toLink())
 		}
 	}
+	sort.SliceStable(urls, func(a, b int) bool { return urls[a].Created.After(urls[b].Created) })
 
 	c.logger.Debug(fmt.Sprintf("found %d urls", len(urls)))
 
 	return urls, nil
 }
 
+// Stats gets details and clicks of a single shortened URL via YOURLS
+func (c *YOURLSClient) Stats(ctx context.Context, shortURL string) (*shortener.Link, error) {
+	u, err := checkURL(fmt.Sprintf("%s/%s", c.baseURL, defaultAPIEndpoint))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("(base) stats url: %s", u.String()))
+
+	v := make(map[string]string)
+	c.authenticate(v)
+	v["action"] = "url-stats"
+	v["format"] = "json"
+	v["shorturl"] = shortURL
+
+	req, err := buildRequestWithContext(ctx, http.MethodPost, u.String(), createPostRequestBody(v))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	c.logger.Debug(
+		fmt.Sprintf("url: %s, method: %s, action: %s", req.URL.String(), req.Method, v["action"]),
+	)
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	c.logger.Debug(fmt.Sprintf("response: %s (%d)", res.Status, res.StatusCode))
+
+	var statsRes urlStatsResponse
+	if err := unmarshalResponseToJSON(res, &statsRes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK || statsRes.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get url stats: %s", statsRes.Message)
+	}
+
+	link := statsRes.Link.toLink()
+	return &link, nil
+}
+
+// CheckConnection checks endpoint and credentials via the harmless "db-stats" action
+func (c *YOURLSClient) CheckConnection(ctx context.Context) (string, error) {
+	u, err := checkURL(fmt.Sprintf("%s/%s", c.baseURL, defaultAPIEndpoint))
+	if err != nil {
+		return "", fmt.Errorf("invalid base url: %w", err)
+	}
+	c.logger.Debug(fmt.Sprintf("(base) db stats url: %s", u.String()))
+
+	v := make(map[string]string)
+	c.authenticate(v)
+	v["action"] = "db-stats"
+	v["format"] = "json"
+
+	req, err := buildRequestWithContext(ctx, http.MethodPost, u.String(), createPostRequestBody(v))
+	if err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	c.logger.Debug(fmt.Sprintf("response: %s (%d)", res.Status, res.StatusCode))
+
+	var statsRes dbStatsResponse
+	if err := unmarshalResponseToJSON(res, &statsRes); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get db stats: %s (%s)", statsRes.Message, res.Status)
+	}
+
+	return fmt.Sprintf(
+		"credentials valid (%s), %v links with %v clicks",
+		c.auth,
+		statsRes.DBStats.TotalLinks,
+		statsRes.DBStats.TotalClicks,
+	), nil
+}
+
 // NewClient creates a new YOURLSClient
 func NewClient(dir string, debug bool, cfg *environment.EnvConfig) *YOURLSClient {
 	return &YOURLSClient{
@@ -206,7 +398,28 @@ func NewClient(dir string, debug bool, cfg *environment.EnvConfig) *YOURLSClient
 			WithConsoleOutput(debug),
 		client:    &http.Client{Timeout: 5 * time.Second},
 		baseURL:   cfg.YourlsEndpoint,
+		auth:      strings.ToLower(cfg.YourlsAuth),
 		signature: cfg.YourlsSignatureKey,
+		username:  cfg.YourlsUsername,
+		password:  cfg.YourlsPassword,
+	}
+}
+
+// Adds the credentials of the configured auth mode to the request values,
+// the values must never be logged
+func (c *YOURLSClient) authenticate(v map[string]string) {
+	switch c.auth {
+	case AuthSignature:
+		v["signature"] = c.signature
+	case AuthPassword:
+		v["username"] = c.username
+		v["password"] = c.password
+	default:
+		// Time limited signature, useless once YOURLS considers the timestamp too old
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		sum := md5.Sum([]byte(timestamp + c.signature))
+		v["timestamp"] = timestamp
+		v["signature"] = hex.EncodeToString(sum[:])
 	}
 }
 
@@ -253,10 +466,21 @@ func unmarshalResponseToJSON(res *http.Response, v interface{}) error {
 	return nil
 }
 
+// Auth modes which can be chosen via LINK_YOURLS_AUTH
+const (
+	// md5(timestamp + signature token) with the timestamp, default
+	AuthTimestamp string = environment.YourlsAuthTimestamp
+	// Plain signature token, never expires
+	AuthSignature string = environment.YourlsAuthSignature
+	// Username and password of a YOURLS user
+	AuthPassword string = environment.YourlsAuthPassword
+)
+
 const (
 	defaultAPIEndpoint string = "yourls-api.php"
 	defaultUploadTitle string = "Uploaded using minio-yourls-uploader by devusSs"
 	defaultLinkLimit   int    = 20
+	timestampFormat    string = "2006-01-02 15:04:05"
 )
 
 type shortenURLErrorResponse struct {
@@ -291,6 +515,27 @@ type expandURLResponse struct {
 	StatusCode int    `json:"statusCode"`
 }
 
+type pluginActionResponse struct {
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+	StatusCode int    `json:"statusCode"`
+}
+
+type urlStatsResponse struct {
+	StatusCode int      `json:"statusCode"`
+	Message    string   `json:"message"`
+	Link       linkData `json:"link"`
+}
+
+type dbStatsResponse struct {
+	Message string `json:"message"`
+	DBStats struct {
+		// Returned as strings or numbers depending on the YOURLS version
+		TotalLinks  any `json:"total_links"`
+		TotalClicks any `json:"total_clicks"`
+	} `json:"db-stats"`
+}
+
 type linkData struct {
 	ShortURL  string `json:"shorturl"`
 	URL       string `json:"url"`
@@ -300,6 +545,16 @@ type linkData struct {
 	Clicks    int    `json:"clicks"`
 }
 
+func (l linkData) toLink() shortener.Link {
+	created, _ := time.ParseInLocation(timestampFormat, l.Timestamp, time.Local)
+	return shortener.Link{
+		ShortURL: l.ShortURL,
+		URL:      l.URL,
+		Created:  created,
+		Clicks:   l.Clicks,
+	}
+}
+
 type links struct {
 	Links map[string]linkData `json:"links"`
 }
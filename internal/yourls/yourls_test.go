@@ -0,0 +1,81 @@
+package yourls
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name string
+		auth string
+		// Expected credentials, the ones of the timestamp mode are checked separately
+		want      map[string]string
+		timestamp bool
+	}{
+		{
+			name:      "timestamp",
+			auth:      AuthTimestamp,
+			timestamp: true,
+		},
+		{
+			name:      "default is timestamp",
+			auth:      "",
+			timestamp: true,
+		},
+		{
+			name: "signature",
+			auth: AuthSignature,
+			want: map[string]string{"signature": "secret-key"},
+		},
+		{
+			name: "password",
+			auth: AuthPassword,
+			want: map[string]string{"username": "admin", "password": "hunter2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &YOURLSClient{
+				auth:      tt.auth,
+				signature: "secret-key",
+				username:  "admin",
+				password:  "hunter2",
+			}
+			v := map[string]string{"action": "shorturl"}
+			before := time.Now().Unix()
+			c.authenticate(v)
+			after := time.Now().Unix()
+
+			if v["action"] != "shorturl" {
+				t.Fatalf("request values were changed: %v", v)
+			}
+			delete(v, "action")
+			if !tt.timestamp {
+				if !maps.Equal(v, tt.want) {
+					t.Fatalf("got %v, want %v", v, tt.want)
+				}
+				return
+			}
+
+			keys := slices.Sorted(maps.Keys(v))
+			if !slices.Equal(keys, []string{"signature", "timestamp"}) {
+				t.Fatalf("got values %v, want signature and timestamp", keys)
+			}
+			timestamp, err := strconv.ParseInt(v["timestamp"], 10, 64)
+			if err != nil || timestamp < before || timestamp > after {
+				t.Fatalf("got timestamp %q, want the current unix time", v["timestamp"])
+			}
+			// The key itself must never be sent in this mode
+			sum := md5.Sum([]byte(v["timestamp"] + "secret-key"))
+			if want := hex.EncodeToString(sum[:]); v["signature"] != want {
+				t.Fatalf("got signature %q, want %q", v["signature"], want)
+			}
+		})
+	}
+}
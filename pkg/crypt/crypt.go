@@ -0,0 +1,278 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Key is the random key of a single encrypted file
+type Key [KeySize]byte
+
+// NewKey returns a new random key
+func NewKey() (Key, error) {
+	var key Key
+	if _, err := rand.Read(key[:]); err != nil {
+		return Key{}, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return key, nil
+}
+
+// ParseKey parses a key encoded by Key.String
+func ParseKey(input string) (Key, error) {
+	var key Key
+	decoded, err := base64.RawURLEncoding.DecodeString(input)
+	if err != nil || len(decoded) != KeySize {
+		return Key{}, ErrInvalidKey
+	}
+	copy(key[:], decoded)
+	return key, nil
+}
+
+// String encodes the key as unpadded URL safe base64 so it can be used in links
+func (k Key) String() string {
+	return base64.RawURLEncoding.EncodeToString(k[:])
+}
+
+// Encrypter encrypts everything written to it in authenticated chunks
+//
+// The stream starts with a header (magic and nonce prefix) followed by chunks of
+// AES-256-GCM sealed plaintext. Every chunk's nonce contains its index and whether
+// it is the last one, so chunks can neither be reordered nor cut off unnoticed.
+type Encrypter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	buf     []byte
+	counter uint32
+	closed  bool
+	err     error
+}
+
+// NewEncrypter writes the header to w and returns an Encrypter, Close has to be
+// called to write the last chunk (w itself is not closed)
+func NewEncrypter(w io.Writer, key Key) (*Encrypter, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, prefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	if _, err := w.Write(append([]byte(magic), prefix...)); err != nil {
+		return nil, err
+	}
+	return &Encrypter{w: w, aead: aead, prefix: prefix, buf: make([]byte, 0, ChunkSize)}, nil
+}
+
+func (e *Encrypter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	if e.closed {
+		return 0, errors.New("write to closed encrypter")
+	}
+	written := 0
+	for len(p) > 0 {
+		// A full chunk is only sealed once we know it is not the last one
+		if len(e.buf) == ChunkSize {
+			if e.err = e.seal(false); e.err != nil {
+				return written, e.err
+			}
+		}
+		n := copy(e.buf[len(e.buf):ChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close seals and writes the last chunk
+func (e *Encrypter) Close() error {
+	if e.err != nil || e.closed {
+		return e.err
+	}
+	e.closed = true
+	e.err = e.seal(true)
+	return e.err
+}
+
+func (e *Encrypter) seal(last bool) error {
+	if e.counter == maxChunks {
+		return errors.New("file too large to encrypt")
+	}
+	sealed := e.aead.Seal(nil, nonce(e.prefix, e.counter, last), e.buf, nil)
+	e.counter++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(sealed)
+	return err
+}
+
+// Decrypter decrypts a stream written by an Encrypter, every chunk is authenticated
+// before its plaintext is written to the underlying writer
+type Decrypter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	buf     []byte
+	counter uint32
+	closed  bool
+	err     error
+}
+
+// NewDecrypter returns a Decrypter writing the plaintext to w, Close has to be
+// called to check that the stream was complete (w itself is not closed)
+func NewDecrypter(w io.Writer, key Key) (*Decrypter, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Decrypter{w: w, aead: aead, buf: make([]byte, 0, sealedChunkSize)}, nil
+}
+
+func (d *Decrypter) Write(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if d.closed {
+		return 0, errors.New("write to closed decrypter")
+	}
+	written := 0
+	for len(p) > 0 {
+		if d.prefix == nil {
+			n := min(HeaderSize-len(d.buf), len(p))
+			d.buf = append(d.buf, p[:n]...)
+			p = p[n:]
+			written += n
+			if len(d.buf) < HeaderSize {
+				continue
+			}
+			if !IsEncrypted(d.buf) {
+				d.err = ErrNotEncrypted
+				return written, d.err
+			}
+			d.prefix = bytes.Clone(d.buf[len(magic):])
+			d.buf = d.buf[:0]
+			continue
+		}
+		// A full chunk is only opened once we know it is not the last one
+		if len(d.buf) == sealedChunkSize {
+			if d.err = d.open(false); d.err != nil {
+				return written, d.err
+			}
+		}
+		n := copy(d.buf[len(d.buf):sealedChunkSize], p)
+		d.buf = d.buf[:len(d.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close opens the last chunk, fails if the stream was truncated
+func (d *Decrypter) Close() error {
+	if d.err != nil || d.closed {
+		return d.err
+	}
+	d.closed = true
+	if d.prefix == nil {
+		d.err = ErrNotEncrypted
+		return d.err
+	}
+	d.err = d.open(true)
+	return d.err
+}
+
+func (d *Decrypter) open(last bool) error {
+	plain, err := d.aead.Open(d.buf[:0], nonce(d.prefix, d.counter, last), d.buf, nil)
+	if err != nil {
+		return ErrAuthentication
+	}
+	d.counter++
+	d.buf = d.buf[:0]
+	_, err = d.w.Write(plain)
+	return err
+}
+
+// IsEncrypted checks if data starts with the header written by an Encrypter
+func IsEncrypted(data []byte) bool {
+	return len(data) >= HeaderSize && bytes.HasPrefix(data, []byte(magic))
+}
+
+// IsEncryptedFile checks if the file starts with the header written by an Encrypter
+func IsEncryptedFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	header := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read file: %w", err)
+	}
+	return IsEncrypted(header), nil
+}
+
+func newAEAD(key Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// Nonce prefix, big endian chunk index and last chunk flag
+func nonce(prefix []byte, counter uint32, last bool) []byte {
+	n := make([]byte, nonceSize)
+	copy(n, prefix)
+	binary.BigEndian.PutUint32(n[prefixSize:], counter)
+	if last {
+		n[nonceSize-1] = 1
+	}
+	return n
+}
+
+var (
+	// ErrInvalidKey is returned for keys which were not encoded by Key.String
+	ErrInvalidKey = errors.New("invalid encryption key")
+	// ErrNotEncrypted is returned when decrypting data without an encryption header
+	ErrNotEncrypted = errors.New("data is not encrypted")
+	// ErrAuthentication is returned if the key is wrong or the data was modified or truncated
+	ErrAuthentication = errors.New("failed to decrypt: wrong key or corrupted data")
+)
+
+const (
+	// Scheme names the format, stored as object metadata of encrypted uploads
+	Scheme string = "aes-256-gcm-stream-v1"
+	// Extension is the file extension of encrypted uploads
+	Extension string = ".enc"
+	// KeySize is the size of a key in bytes
+	KeySize int = 32
+	// ChunkSize is the amount of plaintext sealed per chunk
+	ChunkSize int = 64 * 1024
+	// HeaderSize is the size of the header in front of the first chunk
+	HeaderSize int = len(magic) + prefixSize
+
+	magic           string = "MLENC\x01"
+	nonceSize       int    = 12
+	prefixSize      int    = 7
+	tagSize         int    = 16
+	sealedChunkSize int    = ChunkSize + tagSize
+	maxChunks       uint32 = 1<<32 - 1
+)
@@ -0,0 +1,215 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "single byte", size: 1},
+		{name: "below chunk", size: ChunkSize - 1},
+		{name: "exactly one chunk", size: ChunkSize},
+		{name: "above chunk", size: ChunkSize + 1},
+		{name: "several chunks", size: 3*ChunkSize + 17},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := newTestKey(t)
+			plain := randomBytes(t, tt.size)
+			sealed := encrypt(t, key, plain)
+			if !IsEncrypted(sealed) {
+				t.Fatal("encrypted data has no header")
+			}
+			got, err := decrypt(key, sealed, 1000)
+			if err != nil {
+				t.Fatalf("failed to decrypt: %v", err)
+			}
+			if !bytes.Equal(got, plain) {
+				t.Fatalf("got %d bytes, want the %d bytes encrypted", len(got), len(plain))
+			}
+		})
+	}
+}
+
+func TestDecryptFails(t *testing.T) {
+	key := newTestKey(t)
+	// Two full chunks and a short last one
+	sealed := encrypt(t, key, randomBytes(t, 2*ChunkSize+100))
+	header := sealed[:HeaderSize]
+	chunk := func(index int) []byte {
+		start := HeaderSize + index*sealedChunkSize
+		return sealed[start:min(start+sealedChunkSize, len(sealed))]
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		key  Key
+		want error
+	}{
+		{
+			name: "wrong key",
+			data: sealed,
+			key:  newTestKey(t),
+			want: ErrAuthentication,
+		},
+		{
+			name: "truncated after a chunk",
+			data: join(header, chunk(0), chunk(1)),
+			key:  key,
+			want: ErrAuthentication,
+		},
+		{
+			name: "truncated within a chunk",
+			data: sealed[:len(sealed)-10],
+			key:  key,
+			want: ErrAuthentication,
+		},
+		{
+			name: "header only",
+			data: header,
+			key:  key,
+			want: ErrAuthentication,
+		},
+		{
+			name: "reordered chunks",
+			data: join(header, chunk(1), chunk(0), chunk(2)),
+			key:  key,
+			want: ErrAuthentication,
+		},
+		{
+			name: "duplicated chunk",
+			data: join(header, chunk(0), chunk(0), chunk(1), chunk(2)),
+			key:  key,
+			want: ErrAuthentication,
+		},
+		{
+			name: "modified byte",
+			data: join(header, chunk(0), flipByte(chunk(1), 42), chunk(2)),
+			key:  key,
+			want: ErrAuthentication,
+		},
+		{
+			name: "no header",
+			data: bytes.Repeat([]byte("plain"), 100),
+			key:  key,
+			want: ErrNotEncrypted,
+		},
+		{
+			name: "empty",
+			data: nil,
+			key:  key,
+			want: ErrNotEncrypted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decrypt(tt.key, tt.data, len(tt.data)+1); !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key := newTestKey(t)
+	tests := []struct {
+		name    string
+		input   string
+		want    Key
+		wantErr bool
+	}{
+		{name: "encoded key", input: key.String(), want: key},
+		{name: "empty", input: "", wantErr: true},
+		{name: "too short", input: key.String()[:20], wantErr: true},
+		{name: "padded", input: key.String() + "=", wantErr: true},
+		{name: "not base64", input: "not a key!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKey(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKey) {
+					t.Fatalf("got error %v, want %v", err, ErrInvalidKey)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to parse key: %v", err)
+			}
+			if got != tt.want {
+				t.Fatal("parsed key differs from the encoded one")
+			}
+		})
+	}
+}
+
+func newTestKey(t *testing.T) Key {
+	t.Helper()
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func randomBytes(t *testing.T, size int) []byte {
+	t.Helper()
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func encrypt(t *testing.T, key Key, plain []byte) []byte {
+	t.Helper()
+	var sealed bytes.Buffer
+	enc, err := NewEncrypter(&sealed, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.Write(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return sealed.Bytes()
+}
+
+// Decrypts data written in pieces of the given size
+func decrypt(key Key, data []byte, pieceSize int) ([]byte, error) {
+	var plain bytes.Buffer
+	dec, err := NewDecrypter(&plain, key)
+	if err != nil {
+		return nil, err
+	}
+	for len(data) > 0 {
+		n := min(pieceSize, len(data))
+		if _, err := dec.Write(data[:n]); err != nil {
+			return nil, err
+		}
+		data = data[n:]
+	}
+	if err := dec.Close(); err != nil {
+		return nil, err
+	}
+	return plain.Bytes(), nil
+}
+
+func flipByte(data []byte, index int) []byte {
+	modified := bytes.Clone(data)
+	modified[index] ^= 0xff
+	return modified
+}
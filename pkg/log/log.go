@@ -51,12 +51,13 @@ func (l *Logger) WithDebug(want bool) *Logger {
 
 // Prints a debug message to log output if level is debug
 //
-// May also print to os.Stdout if logger was created with WithConsoleOutput()
+// May also print to os.Stderr if logger was created with WithConsoleOutput()
 func (l *Logger) Debug(msg string) {
 	if e := l.output.Debug(); e.Enabled() {
 		e.Msg(msg)
 		if l.options.wantConsoleOutput {
-			fmt.Printf(
+			fmt.Fprintf(
+				os.Stderr,
 				"%s [%s] %s\n",
 				time.Now().Format(time.RFC3339),
 				color.WhiteString(strings.ToUpper(l.options.name)),
@@ -68,11 +69,12 @@ func (l *Logger) Debug(msg string) {
 
 // Prints an info message to log output
 //
-// May also print to os.Stdout if logger was created with WithConsoleOutput()
+// May also print to os.Stderr if logger was created with WithConsoleOutput()
 func (l *Logger) Info(msg string) {
 	l.output.Info().Msg(msg)
 	if l.options.wantConsoleOutput {
-		fmt.Printf(
+		fmt.Fprintf(
+			os.Stderr,
 			"%s [%s] %s\n",
 			time.Now().Format(time.RFC3339),
 			color.BlueString(strings.ToUpper(l.options.name)),
@@ -83,11 +85,12 @@ func (l *Logger) Info(msg string) {
 
 // Prints a warning message to log output
 //
-// May also print to os.Stdout if logger was created with WithConsoleOutput()
+// May also print to os.Stderr if logger was created with WithConsoleOutput()
 func (l *Logger) Warn(msg string) {
 	l.output.Warn().Msg(msg)
 	if l.options.wantConsoleOutput {
-		fmt.Printf(
+		fmt.Fprintf(
+			os.Stderr,
 			"%s [%s] %s\n",
 			time.Now().Format(time.RFC3339),
 			color.YellowString(strings.ToUpper(l.options.name)),
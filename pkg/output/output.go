@@ -0,0 +1,104 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format decides how results will be rendered
+type Format string
+
+const (
+	// FormatTable renders results as aligned table including a header
+	FormatTable Format = "table"
+	// FormatPlain renders only the most important values, one per line, for shell scripts
+	FormatPlain Format = "plain"
+	// FormatJSON renders results as indented JSON
+	FormatJSON Format = "json"
+	// FormatYAML renders results as YAML
+	FormatYAML Format = "yaml"
+)
+
+// Result is implemented by all command results
+type Result interface {
+	// Header returns the column names for table output
+	Header() []string
+	// Rows returns the rows for table output
+	Rows() [][]string
+}
+
+// Plainer may be implemented by results which want custom plain output,
+// else the rows will be printed tab separated without header
+type Plainer interface {
+	Plain() []string
+}
+
+// ParseFormat parses the given string to a Format, "" will be treated as FormatTable
+func ParseFormat(input string) (Format, error) {
+	switch Format(strings.ToLower(input)) {
+	case "", FormatTable:
+		return FormatTable, nil
+	case FormatPlain:
+		return FormatPlain, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatYAML:
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("invalid output format: %s (supported: json, yaml, table, plain)", input)
+}
+
+// Render writes the result to w using the given format
+func Render(w io.Writer, format Format, result Result) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return fmt.Errorf("failed to render json: %w", err)
+		}
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(result); err != nil {
+			return fmt.Errorf("failed to render yaml: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("failed to render yaml: %w", err)
+		}
+	case FormatPlain:
+		if p, ok := result.(Plainer); ok {
+			for _, line := range p.Plain() {
+				if _, err := fmt.Fprintln(w, line); err != nil {
+					return fmt.Errorf("failed to render plain: %w", err)
+				}
+			}
+			return nil
+		}
+		for _, row := range result.Rows() {
+			if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+				return fmt.Errorf("failed to render plain: %w", err)
+			}
+		}
+	default:
+		rows := result.Rows()
+		if len(rows) == 0 {
+			return nil
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(result.Header(), "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return fmt.Errorf("failed to render table: %w", err)
+		}
+	}
+	return nil
+}
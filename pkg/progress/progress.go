@@ -0,0 +1,212 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/mattn/go-isatty"
+)
+
+// Mode decides how (and if) progress will be reported
+type Mode string
+
+const (
+	// ModeAuto shows a progress bar if stdout is a terminal, nothing otherwise
+	ModeAuto Mode = "auto"
+	// ModeBar always shows a progress bar
+	ModeBar Mode = "bar"
+	// ModeJSON prints one JSON object per update for scripts
+	ModeJSON Mode = "json"
+	// ModeNone disables progress reporting
+	ModeNone Mode = "none"
+)
+
+// ParseMode parses the given string to a Mode, "" will be treated as ModeAuto
+func ParseMode(input string) (Mode, error) {
+	switch Mode(strings.ToLower(input)) {
+	case "", ModeAuto:
+		return ModeAuto, nil
+	case ModeBar:
+		return ModeBar, nil
+	case ModeJSON:
+		return ModeJSON, nil
+	case ModeNone:
+		return ModeNone, nil
+	}
+	return "", fmt.Errorf("invalid progress mode: %s (supported: auto, bar, json, none)", input)
+}
+
+// Reporter tracks transferred bytes and renders them to stderr
+//
+// It implements io.Reader (minio progress convention) and io.Writer
+// so it can be used with minio.PutObjectOptions.Progress and io.TeeReader.
+type Reporter struct {
+	mu       sync.Mutex
+	out      io.Writer
+	mode     Mode
+	name     string
+	total    int64
+	done     int64
+	resumed  int64
+	started  time.Time
+	rendered time.Time
+	finished bool
+}
+
+// Read counts len(p) bytes as transferred, it does not read anything
+func (r *Reporter) Read(p []byte) (int, error) {
+	r.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Write counts len(p) bytes as transferred and discards them
+func (r *Reporter) Write(p []byte) (int, error) {
+	r.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Add marks n more bytes as transferred
+func (r *Reporter) Add(n int64) {
+	if r == nil || r.mode == ModeNone {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done += n
+	if time.Since(r.rendered) >= r.interval() {
+		r.render()
+	}
+}
+
+// Resume marks n bytes as transferred before, they count towards the total
+// but not towards the rate
+func (r *Reporter) Resume(n int64) {
+	if r == nil || r.mode == ModeNone {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done += n
+	r.resumed += n
+}
+
+// Finish renders the final state, further updates will be ignored
+func (r *Reporter) Finish() {
+	if r == nil || r.mode == ModeNone {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.finished {
+		return
+	}
+	r.finished = true
+	r.render()
+	if r.mode == ModeBar {
+		fmt.Fprintln(r.out)
+	}
+}
+
+func (r *Reporter) interval() time.Duration {
+	if r.mode == ModeJSON {
+		return time.Second
+	}
+	return 200 * time.Millisecond
+}
+
+func (r *Reporter) render() {
+	r.rendered = time.Now()
+	elapsed := time.Since(r.started).Seconds()
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(r.done-r.resumed) / elapsed
+	}
+	percent := -1.0
+	eta := -1.0
+	if r.total > 0 {
+		percent = min(float64(r.done)/float64(r.total)*100, 100)
+		if rate > 0 {
+			eta = float64(r.total-r.done) / rate
+		}
+	}
+
+	switch r.mode {
+	case ModeJSON:
+		line, err := json.Marshal(jsonProgress{
+			Name:       r.name,
+			Bytes:      r.done,
+			Total:      r.total,
+			Percent:    percent,
+			Rate:       rate,
+			ETASeconds: eta,
+			Done:       r.finished,
+		})
+		if err != nil {
+			return
+		}
+		fmt.Fprintln(r.out, string(line))
+	case ModeBar:
+		var bar, pct, etaStr string
+		if percent >= 0 {
+			filled := int(percent / 100 * barWidth)
+			bar = "[" + strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled) + "]"
+			pct = fmt.Sprintf("%5.1f%%", percent)
+		}
+		if eta >= 0 {
+			etaStr = "ETA " + time.Duration(eta*float64(time.Second)).Round(time.Second).String()
+		}
+		fmt.Fprintf(
+			r.out,
+			"\r%s %s %s %s/%s %s/s %s\033[K",
+			r.name,
+			bar,
+			pct,
+			humanize.IBytes(uint64(r.done)),
+			humanize.IBytes(uint64(max(r.total, 0))),
+			humanize.IBytes(uint64(rate)),
+			etaStr,
+		)
+	}
+}
+
+// New creates a new progress reporter for a transfer of total bytes (-1 if unknown),
+// ModeAuto will be resolved to ModeBar or ModeNone depending on stdout
+func New(mode Mode, name string, total int64) *Reporter {
+	if mode == ModeAuto || mode == "" {
+		mode = ModeNone
+		if isTerminal(os.Stdout) {
+			mode = ModeBar
+		}
+	}
+	return &Reporter{
+		out:     os.Stderr,
+		mode:    mode,
+		name:    name,
+		total:   total,
+		started: time.Now(),
+	}
+}
+
+const (
+	barWidth = 30
+)
+
+type jsonProgress struct {
+	Name       string  `json:"name"`
+	Bytes      int64   `json:"bytes"`
+	Total      int64   `json:"total"`
+	Percent    float64 `json:"percent"`
+	Rate       float64 `json:"bytes_per_second"`
+	ETASeconds float64 `json:"eta_seconds"`
+	Done       bool    `json:"done"`
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
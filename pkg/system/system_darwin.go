@@ -3,7 +3,9 @@ package system
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -17,3 +19,42 @@ func GetOS(ctx context.Context) (string, error) {
 	osName := strings.TrimSpace(string(output))
 	return osName, nil
 }
+
+// Gets the directory for user specific application data
+// (~/Library/Application Support)
+func GetDataDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting data dir: %w", err)
+	}
+	return filepath.Join(home, "Library", "Application Support"), nil
+}
+
+// Gets the directory for user specific configuration files
+// (~/Library/Application Support)
+func GetConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting config dir: %w", err)
+	}
+	return filepath.Join(home, "Library", "Application Support"), nil
+}
+
+// Shows a desktop notification via AppleScript
+func Notify(ctx context.Context, title string, message string) error {
+	script := fmt.Sprintf(
+		"display notification %s with title %s",
+		quoteAppleScript(message),
+		quoteAppleScript(title),
+	)
+	cmd := exec.CommandContext(ctx, "osascript", "-e", script)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sending notification: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func quoteAppleScript(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
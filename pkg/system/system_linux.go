@@ -3,7 +3,9 @@ package system
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -17,3 +19,38 @@ func GetOS(ctx context.Context) (string, error) {
 	osName := strings.TrimSpace(string(output))
 	return osName, nil
 }
+
+// Gets the directory for user specific application data
+// ($XDG_DATA_HOME or ~/.local/share)
+func GetDataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting data dir: %w", err)
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// Gets the directory for user specific configuration files
+// ($XDG_CONFIG_HOME or ~/.config)
+func GetConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting config dir: %w", err)
+	}
+	return filepath.Join(home, ".config"), nil
+}
+
+// Shows a desktop notification via notify-send (libnotify)
+func Notify(ctx context.Context, title string, message string) error {
+	cmd := exec.CommandContext(ctx, "notify-send", "--app-name=minio-link", title, message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sending notification: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -3,7 +3,9 @@ package system
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -20,3 +22,52 @@ func GetOS(ctx context.Context) (string, error) {
 	}
 	return osVersion, nil
 }
+
+// Gets the directory for user specific application data (%LOCALAPPDATA%)
+func GetDataDir() (string, error) {
+	if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting data dir: %w", err)
+	}
+	return filepath.Join(home, "AppData", "Local"), nil
+}
+
+// Gets the directory for user specific configuration files (%APPDATA%)
+func GetConfigDir() (string, error) {
+	if dir := os.Getenv("APPDATA"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting config dir: %w", err)
+	}
+	return filepath.Join(home, "AppData", "Roaming"), nil
+}
+
+// Shows a desktop notification as balloon tip via PowerShell
+func Notify(ctx context.Context, title string, message string) error {
+	script := `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(5000, $env:LINK_NOTIFY_TITLE, $env:LINK_NOTIFY_MESSAGE, 'Info')
+Start-Sleep -Seconds 5
+$n.Dispose()`
+	cmd := exec.CommandContext(
+		ctx,
+		"powershell",
+		"-NoProfile",
+		"-NonInteractive",
+		"-Command",
+		script,
+	)
+	// Passed via environment so titles and messages never have to be escaped
+	cmd.Env = append(os.Environ(), "LINK_NOTIFY_TITLE="+title, "LINK_NOTIFY_MESSAGE="+message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sending notification: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package timeparse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration works like time.ParseDuration but also supports
+// days ("3d") and weeks ("2w") as whole number units
+func ParseDuration(input string) (time.Duration, error) {
+	input = strings.TrimSpace(input)
+	for suffix, unit := range extraUnits {
+		if !strings.HasSuffix(input, suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(input, suffix))
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", input)
+		}
+		return time.Duration(n) * unit, nil
+	}
+	d, err := time.ParseDuration(input)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %s", input)
+	}
+	return d, nil
+}
+
+// ParseTime parses either an absolute date / time (2006-01-02, 2006-01-02 15:04, RFC3339)
+// or a duration (see ParseDuration) which will be added to now,
+// returns whether the input was a date without time of day
+func ParseTime(input string, now time.Time) (time.Time, bool, error) {
+	input = strings.TrimSpace(input)
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"} {
+		t, err := time.ParseInLocation(layout, input, time.Local)
+		if err == nil {
+			return t, layout == "2006-01-02", nil
+		}
+	}
+	d, err := ParseDuration(input)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf(
+			"invalid time: %s (use a date like 2006-01-02 or a duration like 3d)",
+			input,
+		)
+	}
+	return now.Add(d), false, nil
+}
+
+var extraUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}